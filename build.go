@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Netflix/go-env"
-	"github.com/mattn/go-shellwords"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/fs"
@@ -30,7 +30,40 @@ type SourceRemover interface {
 
 //go:generate faux --interface PublishProcess --output fakes/publish_process.go
 type PublishProcess interface {
-	Execute(workingDir, nugetCachePath, projectPath, outputPath string, debug bool, flags []string) error
+	Execute(workingDir, nugetCachePath string, options PublishOptions, redactor Redactor) error
+}
+
+// PublishOptions describes what the publish process publishes and how.
+// ProjectPath is relative to the working directory. The runtime identifier is
+// only used when neither the flags nor the project set one, unless
+// OverrideRuntimeIdentifier is set, in which case it also takes precedence
+// over the project.
+type PublishOptions struct {
+	ProjectPath               string
+	OutputPath                string
+	Framework                 string
+	RuntimeIdentifier         string
+	OverrideRuntimeIdentifier bool
+	Debug                     bool
+	LockedMode                bool
+	Flags                     []string
+	Properties                MSBuildProperties
+	Project                   ProjectModel
+}
+
+//go:generate faux --interface TestProcess --output fakes/test_process.go
+type TestProcess interface {
+	Execute(workingDir, nugetCachePath string, projectPaths []string, resultsPath string, debug bool, redactor Redactor) error
+}
+
+//go:generate faux --interface HookProcess --output fakes/hook_process.go
+type HookProcess interface {
+	Execute(name, command, workingDir, nugetCachePath, outputPath string, redactor Redactor) error
+}
+
+//go:generate faux --interface RuntimeIdentifierResolver --output fakes/runtime_identifier_resolver.go
+type RuntimeIdentifierResolver interface {
+	Resolve(override string) (string, error)
 }
 
 //go:generate faux --interface BindingResolver --output fakes/binding_resolver.go
@@ -44,13 +77,59 @@ type Slicer interface {
 }
 
 type Configuration struct {
-	LogLevel             string `env:"BP_LOG_LEVEL"`
-	DebugEnabled         bool   `env:"BP_DEBUG_ENABLED"`
-	DisableOutputSlicing bool   `env:"BP_DOTNET_DISABLE_BUILDPACK_OUTPUT_SLICING"`
-	ProjectPath          string `env:"BP_DOTNET_PROJECT_PATH"`
-	PublishFlags         []string
-	RawPublishFlags      string `env:"BP_DOTNET_PUBLISH_FLAGS"`
-	EnablePrerelease     bool   `env:"BP_DOTNET_ENABLE_PRERELEASE"`
+	LogLevel                     string `env:"BP_LOG_LEVEL"`
+	DebugEnabled                 bool   `env:"BP_DEBUG_ENABLED"`
+	DisableOutputSlicing         bool   `env:"BP_DOTNET_DISABLE_BUILDPACK_OUTPUT_SLICING"`
+	ProjectPath                  string `env:"BP_DOTNET_PROJECT_PATH"`
+	ProjectName                  string `env:"BP_DOTNET_PROJECT_NAME"`
+	Framework                    string `env:"BP_DOTNET_FRAMEWORK"`
+	PublishFlags                 []string
+	RawPublishFlags              string        `env:"BP_DOTNET_PUBLISH_FLAGS"`
+	DisablePublishFlagsExpansion bool          `env:"BP_DOTNET_DISABLE_PUBLISH_FLAGS_EXPANSION"`
+	MSBuildProperties            string        `env:"BP_DOTNET_MSBUILD_PROPERTIES"`
+	MSBuildPropertiesFile        string        `env:"BP_DOTNET_MSBUILD_PROPERTIES_FILE"`
+	PublishProfile               string        `env:"BP_DOTNET_PUBLISH_PROFILE"`
+	RuntimeIdentifier            string        `env:"BP_DOTNET_RUNTIME_IDENTIFIER"`
+	RestoreLockedMode            bool          `env:"BP_DOTNET_RESTORE_LOCKED_MODE"`
+	RestoreAttempts              int           `env:"BP_DOTNET_RESTORE_ATTEMPTS,default=3"`
+	RestoreRetryBackoff          time.Duration `env:"BP_DOTNET_RESTORE_RETRY_BACKOFF,default=5s"`
+	PublishTimeout               time.Duration `env:"BP_DOTNET_PUBLISH_TIMEOUT"`
+	BinaryLog                    bool          `env:"BP_DOTNET_BINARY_LOG"`
+	BinaryLogPath                string        `env:"BP_DOTNET_BINARY_LOG_PATH"`
+	RedactPattern                string        `env:"BP_DOTNET_REDACT_PATTERN"`
+	RunTests                     bool          `env:"BP_DOTNET_RUN_TESTS"`
+	TestResultsPath              string        `env:"BP_DOTNET_TEST_RESULTS_PATH,default=TestResults"`
+	PrePublishCommand            string        `env:"BP_DOTNET_PRE_PUBLISH_COMMAND"`
+	PostPublishCommand           string        `env:"BP_DOTNET_POST_PUBLISH_COMMAND"`
+	EnablePrerelease             bool          `env:"BP_DOTNET_ENABLE_PRERELEASE"`
+}
+
+// loadPublishFlags splits BP_DOTNET_PUBLISH_FLAGS, loads the properties of
+// BP_DOTNET_MSBUILD_PROPERTIES and BP_DOTNET_MSBUILD_PROPERTIES_FILE, whose
+// path is relative to the working directory, and parses both together the way
+// they are passed to dotnet publish.
+func (c Configuration) loadPublishFlags(workingDir string) ([]string, MSBuildProperties, PublishFlags, error) {
+	flags, err := SplitPublishFlags(c.RawPublishFlags, !c.DisablePublishFlagsExpansion)
+	if err != nil {
+		return nil, nil, PublishFlags{}, fmt.Errorf("failed to parse flags for dotnet publish: %w", err)
+	}
+
+	var propertiesFile string
+	if c.MSBuildPropertiesFile != "" {
+		propertiesFile = filepath.Join(workingDir, c.MSBuildPropertiesFile)
+	}
+
+	properties, err := LoadMSBuildProperties(c.MSBuildProperties, propertiesFile)
+	if err != nil {
+		return nil, nil, PublishFlags{}, err
+	}
+
+	publishFlags, err := ParsePublishFlags(append(append([]string{}, flags...), properties.Flags()...))
+	if err != nil {
+		return nil, nil, PublishFlags{}, fmt.Errorf("failed to parse flags for dotnet publish: %w", err)
+	}
+
+	return flags, properties, publishFlags, nil
 }
 
 //go:generate faux --interface SBOMGenerator --output fakes/sbom_generator.go
@@ -60,11 +139,15 @@ type SBOMGenerator interface {
 
 func Build(
 	config Configuration,
+	projectParser ProjectParser,
 	sourceRemover SourceRemover,
 	bindingResolver BindingResolver,
 	homeDir string,
 	symlinker SymlinkManager,
 	publishProcess PublishProcess,
+	testProcess TestProcess,
+	hookProcess HookProcess,
+	runtimeIdentifierResolver RuntimeIdentifierResolver,
 	slicer Slicer,
 	clock chronos.Clock,
 	logger scribe.Emitter,
@@ -72,6 +155,26 @@ func Build(
 ) packit.BuildFunc {
 	return func(context packit.BuildContext) (packit.BuildResult, error) {
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
+
+		redactor, err := NewRedactor(config.RedactPattern)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		var (
+			properties   MSBuildProperties
+			publishFlags PublishFlags
+		)
+		config.PublishFlags, properties, publishFlags, err = config.loadPublishFlags(context.WorkingDir)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// The secrets set by the flags and properties are collected before the
+		// configuration is logged, as it contains them as well.
+		redactor = redactor.WithSecrets(sensitivePropertyValues(publishFlags)...)
+		redactor = redactor.WithSecrets(properties.sensitiveValues()...)
+
 		logger.Debug.Process("Build configuration:")
 		es, err := env.Marshal(&config)
 		if err != nil {
@@ -79,7 +182,7 @@ func Build(
 			return packit.BuildResult{}, fmt.Errorf("parsing build configuration: %w", err)
 		}
 		for envVar := range es {
-			logger.Debug.Subprocess("%s: %s", envVar, es[envVar])
+			logger.Debug.Subprocess("%s: %s", envVar, redactor.Redact(es[envVar]))
 		}
 		logger.Debug.Break()
 
@@ -88,20 +191,18 @@ func Build(
 			return packit.BuildResult{}, fmt.Errorf("could not create temp directory: %w", err)
 		}
 
-		shellwordsParser := shellwords.NewParser()
-		shellwordsParser.ParseEnv = true
-
-		config.PublishFlags, err = shellwordsParser.Parse(config.RawPublishFlags)
-		if err != nil {
-			return packit.BuildResult{}, fmt.Errorf("failed to parse flags for dotnet publish: %w", err)
-		}
-
 		globalNugetPath, err := getBinding("nugetconfig", "", context.Platform.Path, "nuget.config", bindingResolver, logger)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
 		if globalNugetPath != "" {
+			secrets, err := NuGetConfigSecrets(globalNugetPath)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			redactor = redactor.WithSecrets(secrets...)
+
 			logger.Debug.Process("Setting up NuGet.Config from service binding")
 			err = symlinker.Link(globalNugetPath, filepath.Join(homeDir, ".nuget", "NuGet", "NuGet.Config"))
 			if err != nil {
@@ -110,6 +211,68 @@ func Build(
 			logger.Debug.Break()
 		}
 
+		projectPath, projectDir, err := resolveProject(context.WorkingDir, config.ProjectPath, config.ProjectName, projectParser)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if projectPath != config.ProjectPath {
+			logger.Debug.Process("Publishing project '%s'", projectPath)
+			logger.Debug.Break()
+		}
+
+		var (
+			project ProjectModel
+			labels  map[string]string
+		)
+		framework := config.Framework
+		if projectPath != projectDir {
+			var profile PublishProfile
+			if config.PublishProfile != "" {
+				profile, err = projectParser.ParsePublishProfile(filepath.Join(context.WorkingDir, projectPath), config.PublishProfile)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+				logger.Process("Using publish profile '%s'", profile.Name)
+				logger.Break()
+			}
+
+			globalProperties := publishGlobalProperties(publishFlags, profile, config.DebugEnabled)
+			project, err = projectParser.ParseProject(filepath.Join(context.WorkingDir, projectPath), context.WorkingDir, globalProperties)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			project.PublishProfile = profile
+
+			// The application type is exported as an image label, where the
+			// platform and other tools can read it.
+			labels = map[string]string{"io.paketo.dotnet.application-type": project.ApplicationType()}
+
+			// BP_DOTNET_FRAMEWORK takes precedence over the target framework of
+			// the publish profile.
+			if framework == "" {
+				framework = project.PublishProfile.Property("TargetFramework")
+			}
+
+			framework, err = project.PublishFramework(framework)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		// The runtime identifier of the build image is only resolved when the
+		// buildpack sets it, so that builds that choose their own runtime do not
+		// depend on the architecture of the build image being supported.
+		var runtimeIdentifier string
+		_, hasRuntimeFlag := runtimeIdentifierFlag(publishFlags)
+		projectRuntimeIdentifier, _ := project.PublishProperty("RuntimeIdentifier")
+		if config.RuntimeIdentifier != "" || !hasRuntimeFlag && projectRuntimeIdentifier == "" {
+			runtimeIdentifier, err = runtimeIdentifierResolver.Resolve(config.RuntimeIdentifier)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
 		nugetCache, err := context.Layers.Get("nuget-cache")
 		if err != nil {
 			return packit.BuildResult{}, err
@@ -129,18 +292,125 @@ func Build(
 		nugetCache.Metadata["stack"] = context.Stack
 		nugetCache.Cache = true
 
+		var binaryLogLayer packit.Layer
+		if config.BinaryLog {
+			// MSBuild only writes one binary log, so a switch of the flags would
+			// leave the layer without the log it is meant to hold.
+			if binaryLogger, ok := publishFlags.Option("-bl"); ok {
+				return packit.BuildResult{}, fmt.Errorf("failed to record binary log: BP_DOTNET_BINARY_LOG conflicts with flag %q of BP_DOTNET_PUBLISH_FLAGS; remove one of them", binaryLogger.Token)
+			}
+
+			binaryLogLayer, err = context.Layers.Get("msbuild-binlog")
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			binaryLogLayer, err = binaryLogLayer.Reset()
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			// The binary log records the environment of the build, so it is
+			// kept in the cache rather than in the app image.
+			binaryLogLayer.Cache = true
+
+			config.PublishFlags = append(config.PublishFlags, fmt.Sprintf("-bl:%s", filepath.Join(binaryLogLayer.Path, "msbuild.binlog")))
+		}
+
+		if config.PrePublishCommand != "" {
+			logger.Process("Running pre-publish hook")
+			err = hookProcess.Execute("pre-publish", config.PrePublishCommand, context.WorkingDir, nugetCache.Path, tempDir, redactor)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		var testResultsDir string
+		if config.RunTests {
+			testResultsDir, err = workspacePath(context.WorkingDir, config.TestResultsPath)
+			if err != nil {
+				return packit.BuildResult{}, fmt.Errorf("failed to run tests: %w", err)
+			}
+
+			testProjects, err := projectParser.FindTestProjects(filepath.Join(context.WorkingDir, config.ProjectPath), context.WorkingDir)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			logger.Process("Running tests")
+			if len(testProjects) == 0 {
+				logger.Subprocess("No test projects found")
+				logger.Break()
+			} else {
+				var projectPaths []string
+				for _, testProject := range testProjects {
+					projectPath, err := filepath.Rel(context.WorkingDir, testProject)
+					if err != nil {
+						return packit.BuildResult{}, fmt.Errorf("failed to resolve test project path: %w", err)
+					}
+					projectPaths = append(projectPaths, projectPath)
+				}
+
+				err = testProcess.Execute(context.WorkingDir, nugetCache.Path, projectPaths, testResultsDir, config.DebugEnabled, redactor)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+				logger.Subprocess("Test results written to %s", testResultsDir)
+				logger.Break()
+			}
+		}
+
 		logger.Process("Executing build process")
-		err = publishProcess.Execute(context.WorkingDir, nugetCache.Path, config.ProjectPath, tempDir, config.DebugEnabled, config.PublishFlags)
+		err = publishProcess.Execute(context.WorkingDir, nugetCache.Path, PublishOptions{
+			ProjectPath:               projectPath,
+			OutputPath:                tempDir,
+			Framework:                 framework,
+			RuntimeIdentifier:         runtimeIdentifier,
+			OverrideRuntimeIdentifier: config.RuntimeIdentifier != "",
+			Debug:                     config.DebugEnabled,
+			LockedMode:                config.RestoreLockedMode,
+			Flags:                     config.PublishFlags,
+			Properties:                properties,
+			Project:                   project,
+		}, redactor)
+		if config.BinaryLog {
+			binaryLog := filepath.Join(binaryLogLayer.Path, "msbuild.binlog")
+			exists, existsErr := fs.Exists(binaryLog)
+			if existsErr != nil {
+				return packit.BuildResult{}, errors.Join(err, existsErr)
+			}
+
+			if exists {
+				logger.Process("MSBuild binary log written to %s", binaryLog)
+
+				if err != nil && config.BinaryLogPath != "" {
+					exportErr := exportBinaryLog(binaryLog, context.WorkingDir, config.BinaryLogPath)
+					if exportErr != nil {
+						return packit.BuildResult{}, errors.Join(err, exportErr)
+					}
+					logger.Subprocess("Exported to %s", filepath.Join(context.WorkingDir, config.BinaryLogPath))
+				}
+				logger.Break()
+			}
+		}
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
+		if config.PostPublishCommand != "" {
+			logger.Process("Running post-publish hook")
+			err = hookProcess.Execute("post-publish", config.PostPublishCommand, context.WorkingDir, nugetCache.Path, tempDir, redactor)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
 		var slices []packit.Slice
 
 		if !config.DisableOutputSlicing {
 			logger.Process("Dividing build output into layers to optimize cache reuse")
 
-			pkg, early, project, err := slicer.Slice(filepath.Join(context.WorkingDir, config.ProjectPath, "obj", "project.assets.json"))
+			pkg, early, project, err := slicer.Slice(filepath.Join(context.WorkingDir, projectDir, "obj", "project.assets.json"))
 			if err != nil {
 				return packit.BuildResult{}, err
 			}
@@ -176,6 +446,13 @@ func Build(
 			return packit.BuildResult{}, err
 		}
 
+		if config.RunTests {
+			err = keepTestResults(testResultsDir, context.WorkingDir, tempDir)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
 		logger.Process("Removing source code")
 		logger.Break()
 		err = sourceRemover.Remove(context.WorkingDir, tempDir)
@@ -206,6 +483,10 @@ func Build(
 			return packit.BuildResult{}, err
 		}
 
+		if config.BinaryLog {
+			layers = append(layers, binaryLogLayer)
+		}
+
 		for _, layer := range layers {
 			logger.Debug.Process("Setting up layer '%s'", layer.Name)
 			logger.Debug.Subprocess("Available at launch: %t", layer.Launch)
@@ -218,6 +499,7 @@ func Build(
 			Layers: layers,
 			Launch: packit.LaunchMetadata{
 				Slices: slices,
+				Labels: labels,
 			},
 			Build: packit.BuildMetadata{
 				SBOM: formattedSBOM,
@@ -226,6 +508,92 @@ func Build(
 	}
 }
 
+// resolveProject returns the path of the project to publish and the directory
+// that contains it, both relative to the working directory. When no project
+// file can be found the configured project path is used as is.
+func resolveProject(workingDir, configuredPath, projectName string, parser ProjectParser) (string, string, error) {
+	projectFile, err := parser.FindProjectFile(filepath.Join(workingDir, configuredPath), workingDir, projectName)
+	if err != nil {
+		return "", "", err
+	}
+
+	if projectFile == "" {
+		return configuredPath, configuredPath, nil
+	}
+
+	projectPath, err := filepath.Rel(workingDir, projectFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	return projectPath, filepath.Dir(projectPath), nil
+}
+
+// workspacePath returns the absolute path of the given path relative to the
+// working directory, which it must be inside of.
+func workspacePath(workingDir, path string) (string, error) {
+	destination := filepath.Join(workingDir, path)
+	relative, err := filepath.Rel(workingDir, destination)
+	if err != nil || relative == "." || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is not inside the working directory", path)
+	}
+
+	return destination, nil
+}
+
+// exportBinaryLog copies the binary log to the given path, which must be
+// inside the working directory.
+func exportBinaryLog(binaryLog, workingDir, path string) error {
+	destination, err := workspacePath(workingDir, path)
+	if err != nil {
+		return fmt.Errorf("failed to export binary log: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to export binary log: %w", err)
+	}
+
+	err = fs.Copy(binaryLog, destination)
+	if err != nil {
+		return fmt.Errorf("failed to export binary log: %w", err)
+	}
+
+	return nil
+}
+
+// keepTestResults moves the test results directory into the publish output,
+// at the same path relative to the working directory, so that the results are
+// kept when the source code is replaced by the publish output.
+func keepTestResults(resultsDir, workingDir, outputDir string) error {
+	exists, err := fs.Exists(resultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to keep test results: %w", err)
+	}
+
+	if !exists {
+		return nil
+	}
+
+	relative, err := filepath.Rel(workingDir, resultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to keep test results: %w", err)
+	}
+
+	destination := filepath.Join(outputDir, relative)
+	err = os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to keep test results: %w", err)
+	}
+
+	err = fs.Move(resultsDir, destination)
+	if err != nil {
+		return fmt.Errorf("failed to keep test results: %w", err)
+	}
+
+	return nil
+}
+
 func getBinding(typ, provider, bindingsRoot, entry string, bindingResolver BindingResolver, logger scribe.Emitter) (string, error) {
 	bindings, err := bindingResolver.Resolve(typ, provider, bindingsRoot)
 	if err != nil {
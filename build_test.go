@@ -3,10 +3,12 @@ package dotnetpublish_test
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
@@ -30,13 +32,17 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		homeDir    string
 		layersDir  string
 
-		bindingResolver *fakes.BindingResolver
-		publishProcess  *fakes.PublishProcess
-		sbomGenerator   *fakes.SBOMGenerator
-		slicer          *fakes.Slicer
-		sourceRemover   *fakes.SourceRemover
-		symlinker       *fakes.SymlinkManager
-		logger          scribe.Emitter
+		bindingResolver           *fakes.BindingResolver
+		projectParser             *fakes.ProjectParser
+		publishProcess            *fakes.PublishProcess
+		testProcess               *fakes.TestProcess
+		hookProcess               *fakes.HookProcess
+		runtimeIdentifierResolver *fakes.RuntimeIdentifierResolver
+		sbomGenerator             *fakes.SBOMGenerator
+		slicer                    *fakes.Slicer
+		sourceRemover             *fakes.SourceRemover
+		symlinker                 *fakes.SymlinkManager
+		logger                    scribe.Emitter
 
 		build packit.BuildFunc
 	)
@@ -55,7 +61,12 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		symlinker = &fakes.SymlinkManager{}
 		sourceRemover = &fakes.SourceRemover{}
 		publishProcess = &fakes.PublishProcess{}
+		testProcess = &fakes.TestProcess{}
+		hookProcess = &fakes.HookProcess{}
+		runtimeIdentifierResolver = &fakes.RuntimeIdentifierResolver{}
+		runtimeIdentifierResolver.ResolveCall.Returns.String = "linux-x64"
 		bindingResolver = &fakes.BindingResolver{}
+		projectParser = &fakes.ProjectParser{}
 		slicer = &fakes.Slicer{}
 
 		slicer.SliceCall.Returns.Pkgs = packit.Slice{Paths: []string{"some-package.dll"}}
@@ -78,11 +89,15 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				RawPublishFlags: "--publishflag value",
 				DebugEnabled:    true,
 			},
+			projectParser,
 			sourceRemover,
 			bindingResolver,
 			homeDir,
 			symlinker,
 			publishProcess,
+			testProcess,
+			hookProcess,
+			runtimeIdentifierResolver,
 			slicer,
 			chronos.DefaultClock,
 			logger,
@@ -196,10 +211,13 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(symlinker.UnlinkCall.CallCount).To(Equal(0))
 
 		Expect(publishProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
-		Expect(publishProcess.ExecuteCall.Receives.ProjectPath).To(Equal(""))
-		Expect(publishProcess.ExecuteCall.Receives.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+`))
-		Expect(publishProcess.ExecuteCall.Receives.Debug).To(BeTrue())
-		Expect(publishProcess.ExecuteCall.Receives.Flags).To(Equal([]string{"--publishflag", "value"}))
+		Expect(publishProcess.ExecuteCall.Receives.Options.ProjectPath).To(Equal(""))
+		Expect(publishProcess.ExecuteCall.Receives.Options.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+`))
+		Expect(publishProcess.ExecuteCall.Receives.Options.RuntimeIdentifier).To(Equal("linux-x64"))
+		Expect(publishProcess.ExecuteCall.Receives.Options.Debug).To(BeTrue())
+		Expect(publishProcess.ExecuteCall.Receives.Options.Flags).To(Equal([]string{"--publishflag", "value"}))
+
+		Expect(runtimeIdentifierResolver.ResolveCall.Receives.Override).To(Equal(""))
 
 		Expect(slicer.SliceCall.Receives.AssetsFile).To(Equal(filepath.Join(workingDir, "obj", "project.assets.json")))
 
@@ -280,24 +298,797 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Layers: packit.Layers{Path: layersDir},
 				Stack:  "some-stack",
 			})
-			Expect(err).NotTo(HaveOccurred())
-
-			Expect(filepath.Join(layersDir, "nuget-cache", "some-cache")).To(BeAnExistingFile())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layersDir, "nuget-cache", "some-cache")).To(BeAnExistingFile())
+		})
+	})
+
+	context("when project path is set via BP_DOTNET_PROJECT_PATH", func() {
+		it.Before(func() {
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					RawPublishFlags: "--publishflag value",
+					ProjectPath:     "some/project/path",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("returns a build result", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+
+			Expect(layer.Name).To(Equal("nuget-cache"))
+			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "nuget-cache")))
+			Expect(layer.Cache).To(BeTrue())
+
+			Expect(sourceRemover.RemoveCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(sourceRemover.RemoveCall.Receives.PublishOutputDir).To(MatchRegexp(`dotnet-publish-output\d+`))
+
+			Expect(publishProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(publishProcess.ExecuteCall.Receives.Options.ProjectPath).To(Equal("some/project/path"))
+			Expect(publishProcess.ExecuteCall.Receives.Options.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+`))
+			Expect(publishProcess.ExecuteCall.Receives.Options.Flags).To(Equal([]string{"--publishflag", "value"}))
+
+			Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
+			Expect(buffer.String()).To(ContainSubstring("Executing build process"))
+			Expect(buffer.String()).To(ContainSubstring("Dividing build output into layers to optimize cache reuse"))
+			Expect(buffer.String()).To(ContainSubstring("Removing source code"))
+		})
+	})
+
+	context("when the project file is found through a solution file", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "src", "app", "app.csproj")
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{
+				TargetFramework: "net8.0",
+				Properties:      map[string]string{"runtimeidentifier": "linux-musl-x64"},
+			}
+		})
+
+		it("publishes the project and slices its output", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(workingDir))
+			Expect(projectParser.FindProjectFileCall.Receives.RootDir).To(Equal(workingDir))
+
+			Expect(publishProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(publishProcess.ExecuteCall.Receives.Options.ProjectPath).To(Equal(filepath.Join("src", "app", "app.csproj")))
+			Expect(publishProcess.ExecuteCall.Receives.Options.Framework).To(BeEmpty())
+			Expect(publishProcess.ExecuteCall.Receives.Options.Project.Property("RuntimeIdentifier")).To(Equal("linux-musl-x64"))
+
+			Expect(slicer.SliceCall.Receives.AssetsFile).To(Equal(filepath.Join(workingDir, "src", "app", "obj", "project.assets.json")))
+		})
+
+		it("does not resolve the runtime identifier of the build image", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runtimeIdentifierResolver.ResolveCall.CallCount).To(Equal(0))
+			Expect(publishProcess.ExecuteCall.Receives.Options.RuntimeIdentifier).To(BeEmpty())
+		})
+
+		context("when BP_DOTNET_RUNTIME_IDENTIFIER is set", func() {
+			it.Before(func() {
+				runtimeIdentifierResolver.ResolveCall.Returns.String = "linux-arm64"
+
+				build = dotnetpublish.Build(
+					dotnetpublish.Configuration{
+						RuntimeIdentifier: "linux-arm64",
+					},
+					projectParser,
+					sourceRemover,
+					bindingResolver,
+					homeDir,
+					symlinker,
+					publishProcess,
+					testProcess,
+					hookProcess,
+					runtimeIdentifierResolver,
+					slicer,
+					chronos.DefaultClock,
+					logger,
+					sbomGenerator,
+				)
+			})
+
+			it("publishes for it over the runtime identifier of the project", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(runtimeIdentifierResolver.ResolveCall.Receives.Override).To(Equal("linux-arm64"))
+				Expect(publishProcess.ExecuteCall.Receives.Options.RuntimeIdentifier).To(Equal("linux-arm64"))
+				Expect(publishProcess.ExecuteCall.Receives.Options.OverrideRuntimeIdentifier).To(BeTrue())
+			})
+		})
+	})
+
+	context("when the publish flags set the runtime identifier", func() {
+		it.Before(func() {
+			runtimeIdentifierResolver.ResolveCall.Returns.Error = errors.New("unsupported architecture")
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					RawPublishFlags: "-r linux-arm64",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("does not resolve the runtime identifier of the build image", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runtimeIdentifierResolver.ResolveCall.CallCount).To(Equal(0))
+			Expect(publishProcess.ExecuteCall.Receives.Options.Flags).To(Equal([]string{"-r", "linux-arm64"}))
+		})
+	})
+
+	context("when the project is chosen via BP_DOTNET_PROJECT_NAME", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "other.csproj")
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					ProjectName: "other",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("publishes the named project", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(workingDir))
+			Expect(projectParser.FindProjectFileCall.Receives.Name).To(Equal("other"))
+
+			Expect(publishProcess.ExecuteCall.Receives.Options.ProjectPath).To(Equal("other.csproj"))
+			Expect(slicer.SliceCall.Receives.AssetsFile).To(Equal(filepath.Join(workingDir, "obj", "project.assets.json")))
+		})
+	})
+
+	context("when the project targets multiple frameworks", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFrameworks: []string{"net6.0", "net8.0"}}
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					Framework: "net8.0",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("publishes the selected framework", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.ParseProjectCall.Receives.RootDir).To(Equal(workingDir))
+			Expect(projectParser.ParseProjectCall.Receives.GlobalProperties).To(Equal(map[string]string{"Configuration": "Release"}))
+
+			Expect(publishProcess.ExecuteCall.Receives.Options.Framework).To(Equal("net8.0"))
+		})
+
+		it("labels the image with the application type of the project", func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel.SDK = "Microsoft.NET.Sdk.Web"
+
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Labels).To(Equal(map[string]string{"io.paketo.dotnet.application-type": "web"}))
+		})
+	})
+
+	context("when the publish flags and MSBuild properties set global properties", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFramework: "net8.0"}
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					RawPublishFlags:   "-p:Configuration=Debug --self-contained",
+					MSBuildProperties: "Flavor=Lite",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("evaluates the project with them", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.ParseProjectCall.Receives.GlobalProperties).To(Equal(map[string]string{
+				"Configuration": "Debug",
+				"SelfContained": "true",
+				"Flavor":        "Lite",
+			}))
+		})
+	})
+
+	context("when a publish profile is chosen via BP_DOTNET_PUBLISH_PROFILE", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFrameworks: []string{"net6.0", "net8.0"}}
+			projectParser.ParsePublishProfileCall.Returns.PublishProfile = dotnetpublish.PublishProfile{
+				Name:       "FolderProfile",
+				Properties: map[string]string{"targetframework": "net8.0"},
+			}
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					PublishProfile: "FolderProfile",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("publishes the project with the profile", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.ParsePublishProfileCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.ParsePublishProfileCall.Receives.Name).To(Equal("FolderProfile"))
+
+			Expect(publishProcess.ExecuteCall.Receives.Options.Framework).To(Equal("net8.0"))
+			Expect(publishProcess.ExecuteCall.Receives.Options.Project.PublishProfile.Name).To(Equal("FolderProfile"))
+			Expect(projectParser.ParseProjectCall.Receives.GlobalProperties).To(Equal(map[string]string{
+				"Configuration":  "Release",
+				"PublishProfile": "FolderProfile",
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Using publish profile 'FolderProfile'"))
+		})
+	})
+
+	context("when a NuGet.Config is provided via service binding", func() {
+		var bindingDir string
+
+		it.Before(func() {
+			var err error
+			bindingDir, err = os.MkdirTemp("", "binding")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(bindingDir, "nuget.config"), []byte(`<configuration>
+  <packageSourceCredentials>
+    <private>
+      <add key="Username" value="some-user" />
+      <add key="ClearTextPassword" value="some-password" />
+    </private>
+  </packageSourceCredentials>
+</configuration>`), 0600)).To(Succeed())
+
+			bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+				servicebindings.Binding{
+					Name: "some-binding",
+					Path: bindingDir,
+					Type: "nugetconfig",
+					Entries: map[string]*servicebindings.Entry{
+						"nuget.config": servicebindings.NewEntry(filepath.Join(bindingDir, "nuget.config")),
+					},
+				},
+			}
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(bindingDir)).To(Succeed())
+		})
+
+		it("symlinks the provided config file into $HOME/.nuget/NuGet/Nuget.Config during build", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Platform: packit.Platform{
+					Path: "some-platform-path",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bindingResolver.ResolveCall.Receives.Typ).To(Equal("nugetconfig"))
+			Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("some-platform-path"))
+
+			Expect(symlinker.LinkCall.Receives.Oldname).To(Equal(filepath.Join(bindingDir, "nuget.config")))
+			Expect(symlinker.LinkCall.Receives.Newname).To(Equal(filepath.Join(homeDir, ".nuget", "NuGet", "NuGet.Config")))
+			Expect(symlinker.UnlinkCall.CallCount).To(Equal(1))
+			Expect(symlinker.UnlinkCall.Receives.Path).To(Equal(filepath.Join(homeDir, ".nuget", "NuGet", "NuGet.Config")))
+		})
+
+		it("redacts the passwords of the NuGet.Config from the build log", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Platform: packit.Platform{
+					Path: "some-platform-path",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			redactor := publishProcess.ExecuteCall.Receives.Redactor
+			Expect(redactor.Redact("-p:Password=some-password")).To(Equal("-p:Password=***"))
+			Expect(redactor.Redact("some-user")).To(Equal("some-user"))
+		})
+	})
+
+	context("when MSBuild properties are set via BP_DOTNET_MSBUILD_PROPERTIES and BP_DOTNET_MSBUILD_PROPERTIES_FILE", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "msbuild.json"), []byte(`{"Deterministic": true}`), 0600)).To(Succeed())
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					MSBuildProperties:     "Version=1.2.3\nDefineConstants=A;B",
+					MSBuildPropertiesFile: "msbuild.json",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("passes the properties to the publish process", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(publishProcess.ExecuteCall.Receives.Options.Properties).To(Equal(dotnetpublish.MSBuildProperties{
+				"Version":         "1.2.3",
+				"DefineConstants": "A;B",
+				"Deterministic":   "true",
+			}))
+		})
+	})
+
+	context("when the flags and properties set secrets", func() {
+		it.Before(func() {
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					RawPublishFlags:   "-p:NuGetPassword=some-flag-password",
+					MSBuildProperties: "SonarToken=some-property-token",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				scribe.NewEmitter(buffer).WithLevel("DEBUG"),
+				sbomGenerator,
+			)
+		})
+
+		it("redacts them from the logged build configuration", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("BP_DOTNET_PUBLISH_FLAGS: -p:NuGetPassword=***"))
+			Expect(buffer.String()).To(ContainSubstring("BP_DOTNET_MSBUILD_PROPERTIES: SonarToken=***"))
+			Expect(buffer.String()).NotTo(ContainSubstring("some-flag-password"))
+			Expect(buffer.String()).NotTo(ContainSubstring("some-property-token"))
+		})
+	})
+
+	context("when output slicer produces an empty slice", func() {
+		it.Before(func() {
+			slicer.SliceCall.Returns.Pkgs = packit.Slice{Paths: []string{}}
+		})
+		it("does not attach empty slices to the build result", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Platform: packit.Platform{
+					Path: "some-platform-path",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Slices).To(Equal([]packit.Slice{
+				{Paths: []string{"some-release-candidate-package.dll"}},
+				{Paths: []string{"some-project.dll"}},
+			}))
+		})
+	})
+	context("when output slicing is turned off via BP_DOTNET_DISABLE_BUILDPACK_OUTPUT_SLICING", func() {
+		it.Before(func() {
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{DisableOutputSlicing: true},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("returns a build result", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(slicer.SliceCall.CallCount).To(BeZero())
+			Expect(result.Launch.Slices).To(BeNil())
+
+			Expect(buffer.String()).NotTo(ContainSubstring("Dividing build output into layers to optimize cache reuse"))
+		})
+	})
+
+	context("when the binary log is enabled via BP_DOTNET_BINARY_LOG", func() {
+		it.Before(func() {
+			publishProcess.ExecuteCall.Stub = func(_, _ string, options dotnetpublish.PublishOptions, _ dotnetpublish.Redactor) error {
+				binaryLog := strings.TrimPrefix(options.Flags[len(options.Flags)-1], "-bl:")
+				return os.WriteFile(binaryLog, []byte("some-binary-log"), 0600)
+			}
+
+			build = dotnetpublish.Build(
+				dotnetpublish.Configuration{
+					BinaryLog:     true,
+					BinaryLogPath: "logs/build.binlog",
+				},
+				projectParser,
+				sourceRemover,
+				bindingResolver,
+				homeDir,
+				symlinker,
+				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
+				slicer,
+				chronos.DefaultClock,
+				logger,
+				sbomGenerator,
+			)
+		})
+
+		it("keeps the binary log in a cached layer", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "0.0.1",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			binaryLog := filepath.Join(layersDir, "msbuild-binlog", "msbuild.binlog")
+			Expect(publishProcess.ExecuteCall.Receives.Options.Flags).To(Equal([]string{"-bl:" + binaryLog}))
+
+			Expect(result.Layers).To(HaveLen(2))
+			Expect(result.Layers[1].Name).To(Equal("msbuild-binlog"))
+			Expect(result.Layers[1].Cache).To(BeTrue())
+			Expect(result.Layers[1].Launch).To(BeFalse())
+			Expect(binaryLog).To(BeARegularFile())
+
+			Expect(buffer.String()).To(ContainSubstring("MSBuild binary log written to " + binaryLog))
+			Expect(filepath.Join(workingDir, "logs", "build.binlog")).NotTo(BeAnExistingFile())
+		})
+
+		context("when the publish process fails", func() {
+			it.Before(func() {
+				stub := publishProcess.ExecuteCall.Stub
+				publishProcess.ExecuteCall.Stub = func(workingDir, nugetCachePath string, options dotnetpublish.PublishOptions, redactor dotnetpublish.Redactor) error {
+					Expect(stub(workingDir, nugetCachePath, options, redactor)).To(Succeed())
+					return errors.New("some-error")
+				}
+			})
+
+			it("exports the binary log to the working directory", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("some-error"))
+
+				content, err := os.ReadFile(filepath.Join(workingDir, "logs", "build.binlog"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("some-binary-log"))
+
+				Expect(buffer.String()).To(ContainSubstring("Exported to " + filepath.Join(workingDir, "logs", "build.binlog")))
+			})
+
+			context("when the publish flags already record a binary log", func() {
+				it.Before(func() {
+					build = dotnetpublish.Build(
+						dotnetpublish.Configuration{
+							BinaryLog:       true,
+							RawPublishFlags: "/bl:other.binlog",
+						},
+						projectParser,
+						sourceRemover,
+						bindingResolver,
+						homeDir,
+						symlinker,
+						publishProcess,
+						testProcess,
+						hookProcess,
+						runtimeIdentifierResolver,
+						slicer,
+						chronos.DefaultClock,
+						logger,
+						sbomGenerator,
+					)
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						BuildpackInfo: packit.BuildpackInfo{
+							Name:    "Some Buildpack",
+							Version: "0.0.1",
+						},
+						Layers: packit.Layers{Path: layersDir},
+					})
+					Expect(err).To(MatchError(`failed to record binary log: BP_DOTNET_BINARY_LOG conflicts with flag "/bl:other.binlog" of BP_DOTNET_PUBLISH_FLAGS; remove one of them`))
+					Expect(publishProcess.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the export path is outside of the working directory", func() {
+				it.Before(func() {
+					build = dotnetpublish.Build(
+						dotnetpublish.Configuration{
+							BinaryLog:     true,
+							BinaryLogPath: "../build.binlog",
+						},
+						projectParser,
+						sourceRemover,
+						bindingResolver,
+						homeDir,
+						symlinker,
+						publishProcess,
+						testProcess,
+						hookProcess,
+						runtimeIdentifierResolver,
+						slicer,
+						chronos.DefaultClock,
+						logger,
+						sbomGenerator,
+					)
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						BuildpackInfo: packit.BuildpackInfo{
+							Name:    "Some Buildpack",
+							Version: "0.0.1",
+						},
+						Layers: packit.Layers{Path: layersDir},
+					})
+					Expect(err).To(MatchError(ContainSubstring("some-error")))
+					Expect(err).To(MatchError(ContainSubstring(`failed to export binary log: "../build.binlog" is not inside the working directory`)))
+				})
+			})
 		})
 	})
 
-	context("when project path is set via BP_DOTNET_PROJECT_PATH", func() {
+	context("when tests are enabled via BP_DOTNET_RUN_TESTS", func() {
 		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "src", "app", "app.csproj")
+			projectParser.FindTestProjectsCall.Returns.StringSlice = []string{filepath.Join(workingDir, "tests", "app.Tests", "app.Tests.csproj")}
+
+			testProcess.ExecuteCall.Stub = func(_, _ string, _ []string, resultsPath string, _ bool, _ dotnetpublish.Redactor) error {
+				Expect(os.MkdirAll(resultsPath, os.ModePerm)).To(Succeed())
+				return os.WriteFile(filepath.Join(resultsPath, "app.Tests.trx"), []byte("some-results"), 0600)
+			}
+
 			build = dotnetpublish.Build(
 				dotnetpublish.Configuration{
-					RawPublishFlags: "--publishflag value",
-					ProjectPath:     "some/project/path",
+					RunTests:        true,
+					TestResultsPath: "reports/tests",
 				},
+				projectParser,
 				sourceRemover,
 				bindingResolver,
 				homeDir,
 				symlinker,
 				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
 				slicer,
 				chronos.DefaultClock,
 				logger,
@@ -305,108 +1096,165 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			)
 		})
 
-		it("returns a build result", func() {
-			result, err := build(packit.BuildContext{
+		it("runs the test projects before publishing and keeps their results", func() {
+			sourceRemover.RemoveCall.Stub = func(_, publishOutputDir string, _ ...string) error {
+				content, err := os.ReadFile(filepath.Join(publishOutputDir, "reports", "tests", "app.Tests.trx"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("some-results"))
+				return nil
+			}
+
+			_, err := build(packit.BuildContext{
 				WorkingDir: workingDir,
 				BuildpackInfo: packit.BuildpackInfo{
 					Name:    "Some Buildpack",
-					Version: "some-version",
+					Version: "0.0.1",
 				},
 				Layers: packit.Layers{Path: layersDir},
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
-			layer := result.Layers[0]
+			Expect(projectParser.FindTestProjectsCall.Receives.Path).To(Equal(workingDir))
+			Expect(projectParser.FindTestProjectsCall.Receives.RootDir).To(Equal(workingDir))
 
-			Expect(layer.Name).To(Equal("nuget-cache"))
-			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "nuget-cache")))
-			Expect(layer.Cache).To(BeTrue())
+			Expect(testProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(testProcess.ExecuteCall.Receives.NugetCachePath).To(Equal(filepath.Join(layersDir, "nuget-cache")))
+			Expect(testProcess.ExecuteCall.Receives.ProjectPaths).To(Equal([]string{"tests/app.Tests/app.Tests.csproj"}))
+			Expect(testProcess.ExecuteCall.Receives.ResultsPath).To(Equal(filepath.Join(workingDir, "reports", "tests")))
+			Expect(publishProcess.ExecuteCall.CallCount).To(Equal(1))
+			Expect(sourceRemover.RemoveCall.CallCount).To(Equal(1))
 
-			Expect(sourceRemover.RemoveCall.Receives.WorkingDir).To(Equal(workingDir))
-			Expect(sourceRemover.RemoveCall.Receives.PublishOutputDir).To(MatchRegexp(`dotnet-publish-output\d+`))
+			Expect(buffer.String()).To(ContainSubstring("Running tests"))
+			Expect(buffer.String()).To(ContainSubstring("Test results written to " + filepath.Join(workingDir, "reports", "tests")))
+		})
 
-			Expect(publishProcess.ExecuteCall.Receives.WorkingDir).To(Equal(workingDir))
-			Expect(publishProcess.ExecuteCall.Receives.ProjectPath).To(Equal("some/project/path"))
-			Expect(publishProcess.ExecuteCall.Receives.OutputPath).To(MatchRegexp(`dotnet-publish-output\d+`))
-			Expect(publishProcess.ExecuteCall.Receives.Flags).To(Equal([]string{"--publishflag", "value"}))
+		context("when no test projects are found", func() {
+			it.Before(func() {
+				projectParser.FindTestProjectsCall.Returns.StringSlice = nil
+			})
 
-			Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
-			Expect(buffer.String()).To(ContainSubstring("Executing build process"))
-			Expect(buffer.String()).To(ContainSubstring("Dividing build output into layers to optimize cache reuse"))
-			Expect(buffer.String()).To(ContainSubstring("Removing source code"))
+			it("publishes the project without running tests", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(testProcess.ExecuteCall.CallCount).To(Equal(0))
+				Expect(publishProcess.ExecuteCall.CallCount).To(Equal(1))
+				Expect(buffer.String()).To(ContainSubstring("No test projects found"))
+			})
 		})
-	})
 
-	context("when a NuGet.Config is provided via service binding", func() {
-		it.Before(func() {
-			bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
-				servicebindings.Binding{
-					Name: "some-binding",
-					Path: "some-binding-path",
-					Type: "nugetconfig",
-					Entries: map[string]*servicebindings.Entry{
-						"nuget.config": servicebindings.NewEntry("some-binding-path"),
+		context("when the tests fail", func() {
+			it.Before(func() {
+				testProcess.ExecuteCall.Stub = nil
+				testProcess.ExecuteCall.Returns.Error = errors.New("some-error")
+			})
+
+			it("does not publish the project", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
 					},
-				},
-			}
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("some-error"))
+
+				Expect(publishProcess.ExecuteCall.CallCount).To(Equal(0))
+			})
 		})
 
-		it("symlinks the provided config file into $HOME/.nuget/NuGet/Nuget.Config during build", func() {
-			_, err := build(packit.BuildContext{
-				WorkingDir: workingDir,
-				BuildpackInfo: packit.BuildpackInfo{
-					Name:    "Some Buildpack",
-					Version: "0.0.1",
-				},
-				Platform: packit.Platform{
-					Path: "some-platform-path",
-				},
+		context("when the results path is outside of the working directory", func() {
+			it.Before(func() {
+				build = dotnetpublish.Build(
+					dotnetpublish.Configuration{
+						RunTests:        true,
+						TestResultsPath: "../results",
+					},
+					projectParser,
+					sourceRemover,
+					bindingResolver,
+					homeDir,
+					symlinker,
+					publishProcess,
+					testProcess,
+					hookProcess,
+					runtimeIdentifierResolver,
+					slicer,
+					chronos.DefaultClock,
+					logger,
+					sbomGenerator,
+				)
 			})
-			Expect(err).NotTo(HaveOccurred())
-			Expect(bindingResolver.ResolveCall.Receives.Typ).To(Equal("nugetconfig"))
-			Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("some-platform-path"))
 
-			Expect(symlinker.LinkCall.Receives.Oldname).To(Equal(filepath.Join("some-binding-path", "nuget.config")))
-			Expect(symlinker.LinkCall.Receives.Newname).To(Equal(filepath.Join(homeDir, ".nuget", "NuGet", "NuGet.Config")))
-			Expect(symlinker.UnlinkCall.CallCount).To(Equal(1))
-			Expect(symlinker.UnlinkCall.Receives.Path).To(Equal(filepath.Join(homeDir, ".nuget", "NuGet", "NuGet.Config")))
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`failed to run tests: "../results" is not inside the working directory`))
+				Expect(testProcess.ExecuteCall.CallCount).To(Equal(0))
+			})
 		})
-	})
 
-	context("when output slicer produces an empty slice", func() {
-		it.Before(func() {
-			slicer.SliceCall.Returns.Pkgs = packit.Slice{Paths: []string{}}
-		})
-		it("does not attach empty slices to the build result", func() {
-			result, err := build(packit.BuildContext{
-				WorkingDir: workingDir,
-				BuildpackInfo: packit.BuildpackInfo{
-					Name:    "Some Buildpack",
-					Version: "0.0.1",
-				},
-				Platform: packit.Platform{
-					Path: "some-platform-path",
-				},
-				Layers: packit.Layers{Path: layersDir},
+		context("when the test projects cannot be found", func() {
+			it.Before(func() {
+				projectParser.FindTestProjectsCall.Returns.Error = errors.New("some-error")
 			})
-			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Launch.Slices).To(Equal([]packit.Slice{
-				{Paths: []string{"some-release-candidate-package.dll"}},
-				{Paths: []string{"some-project.dll"}},
-			}))
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("some-error"))
+			})
 		})
 	})
-	context("when output slicing is turned off via BP_DOTNET_DISABLE_BUILDPACK_OUTPUT_SLICING", func() {
+
+	context("when hooks are set via BP_DOTNET_PRE_PUBLISH_COMMAND and BP_DOTNET_POST_PUBLISH_COMMAND", func() {
+		var steps []string
+
 		it.Before(func() {
+			steps = nil
+			hookProcess.ExecuteCall.Stub = func(name, command, workingDir, nugetCachePath, outputPath string, _ dotnetpublish.Redactor) error {
+				steps = append(steps, fmt.Sprintf("%s: %s in %s with %s and %s", name, command, workingDir, nugetCachePath, outputPath))
+				return nil
+			}
+			publishProcess.ExecuteCall.Stub = func(_, _ string, _ dotnetpublish.PublishOptions, _ dotnetpublish.Redactor) error {
+				steps = append(steps, "publish")
+				return nil
+			}
+
 			build = dotnetpublish.Build(
-				dotnetpublish.Configuration{DisableOutputSlicing: true},
+				dotnetpublish.Configuration{
+					PrePublishCommand:  "dotnet tool run generate",
+					PostPublishCommand: "./stamp.sh",
+				},
+				projectParser,
 				sourceRemover,
 				bindingResolver,
 				homeDir,
 				symlinker,
 				publishProcess,
+				testProcess,
+				hookProcess,
+				runtimeIdentifierResolver,
 				slicer,
 				chronos.DefaultClock,
 				logger,
@@ -414,8 +1262,8 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			)
 		})
 
-		it("returns a build result", func() {
-			result, err := build(packit.BuildContext{
+		it("runs the commands before and after publishing", func() {
+			_, err := build(packit.BuildContext{
 				WorkingDir: workingDir,
 				BuildpackInfo: packit.BuildpackInfo{
 					Name:    "Some Buildpack",
@@ -425,10 +1273,38 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(slicer.SliceCall.CallCount).To(BeZero())
-			Expect(result.Launch.Slices).To(BeNil())
+			outputPath := publishProcess.ExecuteCall.Receives.Options.OutputPath
+			nugetCachePath := filepath.Join(layersDir, "nuget-cache")
+			Expect(steps).To(Equal([]string{
+				fmt.Sprintf("pre-publish: dotnet tool run generate in %s with %s and %s", workingDir, nugetCachePath, outputPath),
+				"publish",
+				fmt.Sprintf("post-publish: ./stamp.sh in %s with %s and %s", workingDir, nugetCachePath, outputPath),
+			}))
 
-			Expect(buffer.String()).NotTo(ContainSubstring("Dividing build output into layers to optimize cache reuse"))
+			Expect(buffer.String()).To(ContainSubstring("Running pre-publish hook"))
+			Expect(buffer.String()).To(ContainSubstring("Running post-publish hook"))
+		})
+
+		context("when the pre-publish command fails", func() {
+			it.Before(func() {
+				hookProcess.ExecuteCall.Stub = nil
+				hookProcess.ExecuteCall.Returns.Error = errors.New("some-error")
+			})
+
+			it("does not publish the project", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "0.0.1",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("some-error"))
+
+				Expect(hookProcess.ExecuteCall.Receives.Name).To(Equal("pre-publish"))
+				Expect(publishProcess.ExecuteCall.CallCount).To(Equal(0))
+			})
 		})
 	})
 
@@ -437,11 +1313,84 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			it.Before(func() {
 				build = dotnetpublish.Build(
 					dotnetpublish.Configuration{RawPublishFlags: "\""},
+					projectParser,
+					sourceRemover,
+					bindingResolver,
+					homeDir,
+					symlinker,
+					publishProcess,
+					testProcess,
+					hookProcess,
+					runtimeIdentifierResolver,
+					slicer,
+					chronos.DefaultClock,
+					logger,
+					sbomGenerator,
+				)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "0.0.1",
+					},
+				})
+				Expect(err).To(MatchError(`failed to parse flags for dotnet publish: invalid flag "\"": unterminated " quote`))
+			})
+		})
+
+		context("when the project file cannot be found", func() {
+			it.Before(func() {
+				projectParser.FindProjectFileCall.Returns.Error = errors.New("some-error")
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "0.0.1",
+					},
+				})
+				Expect(err).To(MatchError("some-error"))
+			})
+		})
+
+		context("when the project cannot be parsed", func() {
+			it.Before(func() {
+				projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
+				projectParser.ParseProjectCall.Returns.Error = errors.New("some-error")
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "0.0.1",
+					},
+				})
+				Expect(err).To(MatchError("some-error"))
+			})
+		})
+
+		context("when the publish profile cannot be parsed", func() {
+			it.Before(func() {
+				projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
+				projectParser.ParsePublishProfileCall.Returns.Error = errors.New("some-error")
+
+				build = dotnetpublish.Build(
+					dotnetpublish.Configuration{
+						PublishProfile: "FolderProfile",
+					},
+					projectParser,
 					sourceRemover,
 					bindingResolver,
 					homeDir,
 					symlinker,
 					publishProcess,
+					testProcess,
+					hookProcess,
+					runtimeIdentifierResolver,
 					slicer,
 					chronos.DefaultClock,
 					logger,
@@ -456,7 +1405,24 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 						Version: "0.0.1",
 					},
 				})
-				Expect(err).To(MatchError("failed to parse flags for dotnet publish: invalid command line string"))
+				Expect(err).To(MatchError("some-error"))
+			})
+		})
+
+		context("when the target framework cannot be selected", func() {
+			it.Before(func() {
+				projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
+				projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFrameworks: []string{"net8.0-android", "net8.0-ios"}}
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "0.0.1",
+					},
+				})
+				Expect(err).To(MatchError("failed to select target framework: none of net8.0-android, net8.0-ios is supported"))
 			})
 		})
 
@@ -478,6 +1444,23 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the runtime identifier cannot be resolved", func() {
+			it.Before(func() {
+				runtimeIdentifierResolver.ResolveCall.Returns.Error = errors.New("some-error")
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					BuildpackInfo: packit.BuildpackInfo{
+						Version: "0.0.1",
+					},
+				})
+				Expect(err).To(MatchError("some-error"))
+				Expect(publishProcess.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
 		context("when the publish process fails", func() {
 			it.Before(func() {
 				publishProcess.ExecuteCall.Returns.Error = errors.New("some-error")
@@ -586,13 +1569,16 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		context("when symlinking the nuget.config path to the binding path fails", func() {
 			it.Before(func() {
+				bindingDir := t.TempDir()
+				Expect(os.WriteFile(filepath.Join(bindingDir, "nuget.config"), []byte("<configuration />"), 0600)).To(Succeed())
+
 				bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
 					servicebindings.Binding{
 						Name: "some-binding",
-						Path: "some-binding-path",
+						Path: bindingDir,
 						Type: "nugetconfig",
 						Entries: map[string]*servicebindings.Entry{
-							"nuget.config": servicebindings.NewEntry("some-binding-path"),
+							"nuget.config": servicebindings.NewEntry(filepath.Join(bindingDir, "nuget.config")),
 						},
 					},
 				}
@@ -660,13 +1646,16 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		context("when removing the symlink between the nuget.config path and the binding path fails", func() {
 			it.Before(func() {
+				bindingDir := t.TempDir()
+				Expect(os.WriteFile(filepath.Join(bindingDir, "nuget.config"), []byte("<configuration />"), 0600)).To(Succeed())
+
 				bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
 					servicebindings.Binding{
 						Name: "some-binding",
-						Path: "some-binding-path",
+						Path: bindingDir,
 						Type: "nugetconfig",
 						Entries: map[string]*servicebindings.Entry{
-							"nuget.config": servicebindings.NewEntry("some-binding-path"),
+							"nuget.config": servicebindings.NewEntry(filepath.Join(bindingDir, "nuget.config")),
 						},
 					},
 				}
@@ -17,15 +17,16 @@ type BuildPlanMetadata struct {
 
 //go:generate faux --interface ProjectParser --output fakes/project_parser.go
 type ProjectParser interface {
-	FindProjectFile(root string) (string, error)
-	ParseVersion(path, rootDir string) (string, error)
-	NodeIsRequired(path string) (bool, error)
-	NPMIsRequired(path string) (bool, error)
+	FindProjectFile(path, rootDir, name string) (string, error)
+	FindTestProjects(path, rootDir string) ([]string, error)
+	ParseProject(path, rootDir string, globalProperties map[string]string) (ProjectModel, error)
+	ParseGlobalJSON(path, rootDir string) (GlobalJSON, error)
+	ParsePublishProfile(path, name string) (PublishProfile, error)
 }
 
 func Detect(config Configuration, parser ProjectParser) packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
-		projectFilePath, err := parser.FindProjectFile(filepath.Join(context.WorkingDir, config.ProjectPath))
+		projectFilePath, err := parser.FindProjectFile(filepath.Join(context.WorkingDir, config.ProjectPath), context.WorkingDir, config.ProjectName)
 		if err != nil {
 			return packit.DetectResult{}, err
 		}
@@ -34,7 +35,30 @@ func Detect(config Configuration, parser ProjectParser) packit.DetectFunc {
 			return packit.DetectResult{}, packit.Fail.WithMessage("no project file found")
 		}
 
-		version, err := parser.ParseVersion(projectFilePath, context.WorkingDir)
+		_, _, publishFlags, err := config.loadPublishFlags(context.WorkingDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		var profile PublishProfile
+		if config.PublishProfile != "" {
+			profile, err = parser.ParsePublishProfile(projectFilePath, config.PublishProfile)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+		}
+
+		project, err := parser.ParseProject(projectFilePath, context.WorkingDir, publishGlobalProperties(publishFlags, profile, config.DebugEnabled))
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		framework := config.Framework
+		if framework == "" {
+			framework = profile.Property("TargetFramework")
+		}
+
+		version, err := project.RuntimeVersion(framework)
 		if err != nil {
 			return packit.DetectResult{}, err
 		}
@@ -44,10 +68,24 @@ func Detect(config Configuration, parser ProjectParser) packit.DetectFunc {
 			return packit.DetectResult{}, err
 		}
 
+		globalJSON, err := parser.ParseGlobalJSON(projectFilePath, context.WorkingDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		prerelease := config.EnablePrerelease || globalJSON.SDK.AllowPrerelease
+
 		var depVersion = fmt.Sprintf("%d.%d.*", semver.Major(), semver.Minor())
-		if config.EnablePrerelease {
+		if prerelease {
 			depVersion = "~" + depVersion + "-0"
 		}
+		versionSource := filepath.Base(projectFilePath)
+		runtimeVersion := depVersion
+
+		if constraint := globalJSON.SDKConstraint(prerelease); constraint != "" {
+			depVersion = constraint
+			versionSource = "global.json"
+		}
 
 		requirements := []packit.BuildPlanRequirement{
 			{
@@ -55,40 +93,64 @@ func Detect(config Configuration, parser ProjectParser) packit.DetectFunc {
 				Metadata: BuildPlanMetadata{
 					Build:         true,
 					Version:       depVersion,
-					VersionSource: filepath.Base(projectFilePath),
+					VersionSource: versionSource,
 				},
 			},
 		}
 
-		requirements = append(requirements, packit.BuildPlanRequirement{
-			Name: "icu",
-			Metadata: BuildPlanMetadata{
-				Build: true,
-			},
-		})
+		if project.RequiresASPNETCore() {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "dotnet-aspnetcore",
+				Metadata: BuildPlanMetadata{
+					Version:       runtimeVersion,
+					VersionSource: filepath.Base(projectFilePath),
+					Launch:        true,
+				},
+			})
+		}
 
-		nodeReq, err := parser.NodeIsRequired(projectFilePath)
-		if err != nil {
-			return packit.DetectResult{}, err
+		// Apps that use invariant globalization never load ICU.
+		if !project.InvariantGlobalization {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "icu",
+				Metadata: BuildPlanMetadata{
+					Build: true,
+				},
+			})
 		}
 
-		if nodeReq {
+		if project.NodeIsRequired() {
 			requirements = append(requirements, packit.BuildPlanRequirement{
 				Name: "node",
+				Metadata: BuildPlanMetadata{
+					Version:       project.NodeVersion,
+					VersionSource: project.NodeVersionSource,
+					Build:         true,
+				},
+			})
+		}
+
+		if project.NPMIsRequired() {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "npm",
 				Metadata: BuildPlanMetadata{
 					Build: true,
 				},
 			})
 		}
 
-		npmReq, err := parser.NPMIsRequired(projectFilePath)
-		if err != nil {
-			return packit.DetectResult{}, err
+		if project.YarnIsRequired() {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "yarn",
+				Metadata: BuildPlanMetadata{
+					Build: true,
+				},
+			})
 		}
 
-		if npmReq {
+		if project.PNPMIsRequired() {
 			requirements = append(requirements, packit.BuildPlanRequirement{
-				Name: "npm",
+				Name: "pnpm",
 				Metadata: BuildPlanMetadata{
 					Build: true,
 				},
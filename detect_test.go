@@ -30,7 +30,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 
 		projectParser = &fakes.ProjectParser{}
 		projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "app.csproj")
-		projectParser.ParseVersionCall.Returns.String = "6.0.0"
+		projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFramework: "net6.0"}
 
 		detect = dotnetpublish.Detect(
 			dotnetpublish.Configuration{},
@@ -71,16 +71,45 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			},
 		}))
 
-		Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
-		Expect(projectParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
-		Expect(projectParser.ParseVersionCall.Receives.RootDir).To(Equal(workingDir))
-		Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
-		Expect(projectParser.NPMIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+		Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(workingDir))
+		Expect(projectParser.FindProjectFileCall.Receives.Name).To(Equal(""))
+		Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+		Expect(projectParser.ParseProjectCall.Receives.RootDir).To(Equal(workingDir))
+		Expect(projectParser.ParseProjectCall.Receives.GlobalProperties).To(Equal(map[string]string{"Configuration": "Release"}))
+	})
+
+	context("when the publish flags and MSBuild properties set global properties", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "build.properties"), []byte("SelfContained=true\n"), 0600)).To(Succeed())
+
+			detect = dotnetpublish.Detect(
+				dotnetpublish.Configuration{
+					RawPublishFlags:       "-c Debug --runtime linux-arm64",
+					MSBuildProperties:     "Flavor=Lite",
+					MSBuildPropertiesFile: "build.properties",
+				},
+				projectParser,
+			)
+		})
+
+		it("evaluates the project with them", func() {
+			_, err := detect(packit.DetectContext{WorkingDir: workingDir})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.ParseProjectCall.Receives.GlobalProperties).To(Equal(map[string]string{
+				"Configuration":     "Debug",
+				"RuntimeIdentifier": "linux-arm64",
+				"Flavor":            "Lite",
+				"SelfContained":     "true",
+			}))
+		})
 	})
 
 	context("when node is required", func() {
 		it.Before(func() {
-			projectParser.NodeIsRequiredCall.Returns.Bool = true
+			projectParser.ParseProjectCall.Returns.ProjectModel.Targets = []dotnetpublish.ProjectTarget{
+				{Name: "BuildFrontend", Execs: []dotnetpublish.ProjectExec{{Command: "node build.js"}}},
+			}
 		})
 
 		it("requires node in the build plan", func() {
@@ -118,18 +147,42 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
-			Expect(projectParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
-			Expect(projectParser.ParseVersionCall.Receives.RootDir).To(Equal(workingDir))
-			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
-			Expect(projectParser.NPMIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(workingDir))
+			Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.ParseProjectCall.Receives.RootDir).To(Equal(workingDir))
+		})
+	})
+
+	context("when the node version of the app is constrained", func() {
+		it.Before(func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel.Targets = []dotnetpublish.ProjectTarget{
+				{Name: "BuildFrontend", Execs: []dotnetpublish.ProjectExec{{Command: "node build.js"}}},
+			}
+			projectParser.ParseProjectCall.Returns.ProjectModel.NodeVersion = "^18.0.0"
+			projectParser.ParseProjectCall.Returns.ProjectModel.NodeVersionSource = "package.json"
+		})
+
+		it("requires that node version in the build plan", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires[2]).To(Equal(packit.BuildPlanRequirement{
+				Name: "node",
+				Metadata: dotnetpublish.BuildPlanMetadata{
+					Version:       "^18.0.0",
+					VersionSource: "package.json",
+					Build:         true,
+				},
+			}))
 		})
 	})
 
 	context("when npm is required", func() {
 		it.Before(func() {
-			projectParser.NodeIsRequiredCall.Returns.Bool = true
-			projectParser.NPMIsRequiredCall.Returns.Bool = true
+			projectParser.ParseProjectCall.Returns.ProjectModel.Targets = []dotnetpublish.ProjectTarget{
+				{Name: "BuildFrontend", Execs: []dotnetpublish.ProjectExec{{Command: "npm install"}}},
+			}
 		})
 
 		it("requires node in the build plan", func() {
@@ -173,11 +226,9 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
-			Expect(projectParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
-			Expect(projectParser.ParseVersionCall.Receives.RootDir).To(Equal(workingDir))
-			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
-			Expect(projectParser.NPMIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(workingDir))
+			Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.ParseProjectCall.Receives.RootDir).To(Equal(workingDir))
 		})
 	})
 
@@ -223,11 +274,317 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(filepath.Join(workingDir, "src/proj1")))
-			Expect(projectParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src/proj1", "app.csproj")))
-			Expect(projectParser.ParseVersionCall.Receives.RootDir).To(Equal(workingDir))
-			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src/proj1", "app.csproj")))
-			Expect(projectParser.NPMIsRequiredCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src/proj1", "app.csproj")))
+			Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src/proj1")))
+			Expect(projectParser.FindProjectFileCall.Receives.RootDir).To(Equal(workingDir))
+			Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src/proj1", "app.csproj")))
+			Expect(projectParser.ParseProjectCall.Receives.RootDir).To(Equal(workingDir))
+		})
+	})
+
+	context("when the project is chosen via $BP_DOTNET_PROJECT_NAME", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = filepath.Join(workingDir, "other.csproj")
+			detect = dotnetpublish.Detect(
+				dotnetpublish.Configuration{ProjectName: "other"},
+				projectParser,
+			)
+		})
+
+		it("finds the named project and passes detection", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires[0]).To(Equal(packit.BuildPlanRequirement{
+				Name: "dotnet-sdk",
+				Metadata: dotnetpublish.BuildPlanMetadata{
+					Version:       "6.0.*",
+					VersionSource: "other.csproj",
+					Build:         true,
+				},
+			}))
+
+			Expect(projectParser.FindProjectFileCall.Receives.Path).To(Equal(workingDir))
+			Expect(projectParser.FindProjectFileCall.Receives.Name).To(Equal("other"))
+			Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "other.csproj")))
+		})
+	})
+
+	context("when a target framework is chosen via $BP_DOTNET_FRAMEWORK", func() {
+		it.Before(func() {
+			detect = dotnetpublish.Detect(
+				dotnetpublish.Configuration{Framework: "net6.0"},
+				projectParser,
+			)
+		})
+
+		it("requires the SDK of that framework", func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFrameworks: []string{"net6.0", "net8.0"}}
+
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires[0].Metadata).To(Equal(dotnetpublish.BuildPlanMetadata{
+				Version:       "6.0.*",
+				VersionSource: "app.csproj",
+				Build:         true,
+			}))
+
+			Expect(projectParser.ParseProjectCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+		})
+	})
+
+	context("when a publish profile is chosen via $BP_DOTNET_PUBLISH_PROFILE", func() {
+		it.Before(func() {
+			detect = dotnetpublish.Detect(
+				dotnetpublish.Configuration{PublishProfile: "FolderProfile"},
+				projectParser,
+			)
+		})
+
+		it("requires the SDK of the target framework of the profile", func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFrameworks: []string{"net6.0", "net8.0"}}
+			projectParser.ParsePublishProfileCall.Returns.PublishProfile = dotnetpublish.PublishProfile{
+				Name:       "FolderProfile",
+				Properties: map[string]string{"targetframework": "net8.0"},
+			}
+
+			result, err := detect(packit.DetectContext{WorkingDir: workingDir})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires[0]).To(Equal(packit.BuildPlanRequirement{
+				Name: "dotnet-sdk",
+				Metadata: dotnetpublish.BuildPlanMetadata{
+					Version:       "8.0.*",
+					VersionSource: "app.csproj",
+					Build:         true,
+				},
+			}))
+
+			Expect(projectParser.ParsePublishProfileCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.ParsePublishProfileCall.Receives.Name).To(Equal("FolderProfile"))
+		})
+
+		it("evaluates the project with the configuration of the profile", func() {
+			projectParser.ParsePublishProfileCall.Returns.PublishProfile = dotnetpublish.PublishProfile{
+				Name:       "FolderProfile",
+				Properties: map[string]string{"lastusedbuildconfiguration": "Staging"},
+			}
+
+			_, err := detect(packit.DetectContext{WorkingDir: workingDir})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(projectParser.ParseProjectCall.Receives.GlobalProperties).To(Equal(map[string]string{
+				"Configuration":  "Staging",
+				"PublishProfile": "FolderProfile",
+			}))
+		})
+	})
+
+	context("when the project is an ASP.NET Core app", func() {
+		it.Before(func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{
+				SDK:             "Microsoft.NET.Sdk.Web",
+				OutputType:      "Exe",
+				TargetFramework: "net8.0",
+			}
+		})
+
+		it("requires the ASP.NET Core runtime at launch", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-sdk",
+					Metadata: dotnetpublish.BuildPlanMetadata{
+						Version:       "8.0.*",
+						VersionSource: "app.csproj",
+						Build:         true,
+					},
+				},
+				{
+					Name: "dotnet-aspnetcore",
+					Metadata: dotnetpublish.BuildPlanMetadata{
+						Version:       "8.0.*",
+						VersionSource: "app.csproj",
+						Launch:        true,
+					},
+				},
+				{
+					Name: "icu",
+					Metadata: dotnetpublish.BuildPlanMetadata{
+						Build: true,
+					},
+				},
+			}))
+		})
+
+		context("when the SDK version is pinned in global.json", func() {
+			it.Before(func() {
+				globalJSON := dotnetpublish.GlobalJSON{Path: filepath.Join(workingDir, "global.json")}
+				globalJSON.SDK.Version = "8.0.100"
+				projectParser.ParseGlobalJSONCall.Returns.GlobalJSON = globalJSON
+			})
+
+			it("requires the runtime of the target framework", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires[1]).To(Equal(packit.BuildPlanRequirement{
+					Name: "dotnet-aspnetcore",
+					Metadata: dotnetpublish.BuildPlanMetadata{
+						Version:       "8.0.*",
+						VersionSource: "app.csproj",
+						Launch:        true,
+					},
+				}))
+			})
+		})
+	})
+
+	context("when yarn and pnpm are required", func() {
+		it.Before(func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel.Targets = []dotnetpublish.ProjectTarget{
+				{Name: "Frontend", Execs: []dotnetpublish.ProjectExec{
+					{Command: "cd ClientApp && yarn install"},
+					{Command: "/usr/local/bin/pnpm build"},
+				}},
+			}
+		})
+
+		it("requires node, yarn and pnpm in the build plan", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, requirement := range result.Plan.Requires {
+				names = append(names, requirement.Name)
+			}
+			Expect(names).To(Equal([]string{"dotnet-sdk", "icu", "node", "yarn", "pnpm"}))
+			Expect(result.Plan.Requires[3].Metadata).To(Equal(dotnetpublish.BuildPlanMetadata{Build: true}))
+			Expect(result.Plan.Requires[4].Metadata).To(Equal(dotnetpublish.BuildPlanMetadata{Build: true}))
+		})
+	})
+
+	context("when the app uses invariant globalization", func() {
+		it.Before(func() {
+			projectParser.ParseProjectCall.Returns.ProjectModel.InvariantGlobalization = true
+		})
+
+		it("does not require icu", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: "dotnet-sdk",
+					Metadata: dotnetpublish.BuildPlanMetadata{
+						Version:       "6.0.*",
+						VersionSource: "app.csproj",
+						Build:         true,
+					},
+				},
+			}))
+		})
+
+		context("when it is set for the Release|AnyCPU configuration and platform", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), []byte(`
+					<Project Sdk="Microsoft.NET.Sdk">
+					  <PropertyGroup>
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
+					  <PropertyGroup Condition="'$(Configuration)|$(Platform)'=='Release|AnyCPU'">
+					    <InvariantGlobalization>true</InvariantGlobalization>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+
+				detect = dotnetpublish.Detect(dotnetpublish.Configuration{}, dotnetpublish.NewProjectFileParser())
+			})
+
+			it("does not require icu", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				var names []string
+				for _, requirement := range result.Plan.Requires {
+					names = append(names, requirement.Name)
+				}
+				Expect(names).NotTo(ContainElement("icu"))
+			})
+		})
+	})
+
+	context("when the SDK version is pinned in global.json", func() {
+		it.Before(func() {
+			globalJSON := dotnetpublish.GlobalJSON{Path: filepath.Join(workingDir, "global.json")}
+			globalJSON.SDK.Version = "6.0.100"
+			globalJSON.SDK.RollForward = "latestFeature"
+			projectParser.ParseGlobalJSONCall.Returns.GlobalJSON = globalJSON
+		})
+
+		it("requires the pinned SDK version in the build plan", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires[0]).To(Equal(packit.BuildPlanRequirement{
+				Name: "dotnet-sdk",
+				Metadata: dotnetpublish.BuildPlanMetadata{
+					Version:       ">= 6.0.100, < 6.1.0",
+					VersionSource: "global.json",
+					Build:         true,
+				},
+			}))
+
+			Expect(projectParser.ParseGlobalJSONCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+			Expect(projectParser.ParseGlobalJSONCall.Receives.RootDir).To(Equal(workingDir))
+		})
+
+		context("when global.json allows prereleases", func() {
+			it.Before(func() {
+				projectParser.ParseGlobalJSONCall.Returns.GlobalJSON.SDK.AllowPrerelease = true
+			})
+
+			it("includes prereleases in the SDK version constraint", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires[0].Metadata).To(Equal(dotnetpublish.BuildPlanMetadata{
+					Version:       ">= 6.0.100-0, < 6.1.0-0",
+					VersionSource: "global.json",
+					Build:         true,
+				}))
+			})
+		})
+	})
+
+	context("when global.json allows prereleases without pinning a version", func() {
+		it.Before(func() {
+			projectParser.ParseGlobalJSONCall.Returns.GlobalJSON.Path = filepath.Join(workingDir, "global.json")
+			projectParser.ParseGlobalJSONCall.Returns.GlobalJSON.SDK.AllowPrerelease = true
+		})
+
+		it("includes prereleases in the project file version constraint", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires[0].Metadata).To(Equal(dotnetpublish.BuildPlanMetadata{
+				Version:       "~6.0.*-0",
+				VersionSource: "app.csproj",
+				Build:         true,
+			}))
 		})
 	})
 
@@ -258,36 +615,65 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
-		context("when parsing for SDK version errors", func() {
+		context("when the publish flags cannot be parsed", func() {
+			it.Before(func() {
+				detect = dotnetpublish.Detect(
+					dotnetpublish.Configuration{RawPublishFlags: "-c Debug --configuration Release"},
+					projectParser,
+				)
+			})
+
+			it("errors", func() {
+				_, err := detect(packit.DetectContext{WorkingDir: workingDir})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse flags for dotnet publish")))
+			})
+		})
+
+		context("when parsing the project errors", func() {
+			it.Before(func() {
+				projectParser.ParseProjectCall.Returns.Error = errors.New("parsing-project-error")
+			})
+
+			it("errors", func() {
+				_, err := detect(packit.DetectContext{WorkingDir: workingDir})
+				Expect(err).To(MatchError("parsing-project-error"))
+			})
+		})
+
+		context("when parsing the publish profile errors", func() {
 			it.Before(func() {
-				projectParser.ParseVersionCall.Returns.Error = errors.New("parsing-version-error")
+				detect = dotnetpublish.Detect(
+					dotnetpublish.Configuration{PublishProfile: "FolderProfile"},
+					projectParser,
+				)
+				projectParser.ParsePublishProfileCall.Returns.Error = errors.New("parsing-publish-profile-error")
 			})
 
 			it("errors", func() {
 				_, err := detect(packit.DetectContext{WorkingDir: workingDir})
-				Expect(err).To(MatchError("parsing-version-error"))
+				Expect(err).To(MatchError("parsing-publish-profile-error"))
 			})
 		})
 
-		context("when parsing for Node errors", func() {
+		context("when the project does not target a supported framework", func() {
 			it.Before(func() {
-				projectParser.NodeIsRequiredCall.Returns.Error = errors.New("parsing-error")
+				projectParser.ParseProjectCall.Returns.ProjectModel = dotnetpublish.ProjectModel{TargetFramework: "netstandard2.0"}
 			})
 
 			it("errors", func() {
 				_, err := detect(packit.DetectContext{WorkingDir: workingDir})
-				Expect(err).To(MatchError("parsing-error"))
+				Expect(err).To(MatchError("failed to find version in project file: missing or invalid TargetFramework property"))
 			})
 		})
 
-		context("when parsing for NPM errors", func() {
+		context("when parsing global.json errors", func() {
 			it.Before(func() {
-				projectParser.NPMIsRequiredCall.Returns.Error = errors.New("parsing-error")
+				projectParser.ParseGlobalJSONCall.Returns.Error = errors.New("parsing-global-json-error")
 			})
 
 			it("errors", func() {
 				_, err := detect(packit.DetectContext{WorkingDir: workingDir})
-				Expect(err).To(MatchError("parsing-error"))
+				Expect(err).To(MatchError("parsing-global-json-error"))
 			})
 		})
 	})
@@ -0,0 +1,71 @@
+package dotnetpublish
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// buildServerShutdownTimeout bounds the time spent shutting down the build
+// servers once dotnet is done.
+const buildServerShutdownTimeout = 30 * time.Second
+
+// defaultEnvironment is added to the environment of dotnet unless the
+// variables are already set. It keeps the banners, telemetry notices and
+// terminal logger animations of the .NET CLI out of the build log.
+var defaultEnvironment = []string{
+	"DOTNET_NOLOGO=true",
+	"DOTNET_CLI_TELEMETRY_OPTOUT=true",
+	"MSBUILDTERMINALLOGGER=off",
+}
+
+// environment returns the environment of dotnet: the environment of the
+// buildpack, the defaults it does not override and the NuGet cache.
+func environment(nugetCachePath string) []string {
+	env := os.Environ()
+	for _, variable := range defaultEnvironment {
+		name, _, _ := strings.Cut(variable, "=")
+		if _, ok := os.LookupEnv(name); !ok {
+			env = append(env, variable)
+		}
+	}
+
+	return append(env, fmt.Sprintf("NUGET_PACKAGES=%s", nugetCachePath))
+}
+
+// msbuildVerbosity returns the MSBuild verbosity matching the log level of the
+// buildpack: normal for DEBUG and minimal otherwise.
+func msbuildVerbosity(level string) string {
+	if strings.EqualFold(level, "DEBUG") {
+		return "normal"
+	}
+	return "minimal"
+}
+
+// shutdownBuildServers stops the MSBuild nodes and compiler servers that
+// dotnet leaves running, so that they do not outlive the build. Failing to
+// shut them down does not fail the build.
+func shutdownBuildServers(executable Executable, logger scribe.Emitter, workingDir, nugetCachePath string) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildServerShutdownTimeout)
+	defer cancel()
+
+	args := []string{"build-server", "shutdown"}
+	logger.Debug.Subprocess("Running 'dotnet %s'", strings.Join(args, " "))
+
+	err := executable.Execute(ctx, pexec.Execution{
+		Args:   args,
+		Dir:    workingDir,
+		Env:    environment(nugetCachePath),
+		Stdout: logger.Debug.ActionWriter,
+		Stderr: logger.Debug.ActionWriter,
+	})
+	if err != nil {
+		logger.Debug.Action("Failed to shut down build servers: %s", err)
+	}
+	logger.Debug.Break()
+}
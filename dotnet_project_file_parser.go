@@ -1,168 +1,629 @@
 package dotnetpublish
 
 import (
+	"bytes"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/paketo-buildpacks/dotnet-publish/internal"
 )
 
-type ProjectFileParser struct{}
+var projectFileExtensions = []string{".csproj", ".fsproj", ".vbproj"}
+
+type ProjectFileParser struct {
+	projects map[projectKey]ProjectModel
+}
+
+// projectKey identifies an evaluated project: the root directory decides which
+// Directory.Build.props and Directory.Build.targets files are imported, and
+// the global properties which conditions hold, so the same project file
+// evaluates differently under different roots and properties.
+type projectKey struct {
+	path             string
+	rootDir          string
+	globalProperties string
+}
 
 func NewProjectFileParser() ProjectFileParser {
-	return ProjectFileParser{}
+	return ProjectFileParser{
+		projects: map[projectKey]ProjectModel{},
+	}
 }
 
-func (p ProjectFileParser) FindProjectFile(path string) (string, error) {
-	projectFiles, err := filepath.Glob(filepath.Join(path, "*.csproj"))
+// FindProjectFile returns the project file in the given directory, or the
+// startup project of the solution (.sln) or solution filter (.slnf) file in it.
+// The projects of a solution are evaluated with the Directory.Build.props and
+// Directory.Build.targets files up to rootDir taken into account.
+func (p ProjectFileParser) FindProjectFile(path, rootDir, name string) (string, error) {
+	var projectFiles []string
+	for _, extension := range projectFileExtensions {
+		files, err := filepath.Glob(filepath.Join(path, "*"+extension))
+		if err != nil {
+			return "", err
+		}
+		projectFiles = append(projectFiles, files...)
+	}
+
+	if len(projectFiles) > 0 {
+		return selectProjectFile(path, projectFiles, name)
+	}
+
+	solutionFile, err := findSolutionFile(path)
 	if err != nil {
 		return "", err
 	}
 
-	fsProjFiles, err := filepath.Glob(filepath.Join(path, "*.fsproj"))
+	if solutionFile == "" {
+		return "", nil
+	}
+
+	return p.findStartupProject(solutionFile, rootDir, name)
+}
+
+// FindTestProjects returns the test projects of the solution (.sln) or
+// solution filter (.slnf) file in the given directory, in the order of the
+// solution. It returns no projects when the directory contains a project file
+// rather than a solution, or neither.
+func (p ProjectFileParser) FindTestProjects(path, rootDir string) ([]string, error) {
+	for _, extension := range projectFileExtensions {
+		files, err := filepath.Glob(filepath.Join(path, "*"+extension))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) > 0 {
+			return nil, nil
+		}
+	}
+
+	solutionFile, err := findSolutionFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if solutionFile == "" {
+		return nil, nil
+	}
+
+	projects, err := parseSolutionProjects(solutionFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var testProjects []string
+	for _, project := range projects {
+		model, err := p.ParseProject(project, rootDir, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if model.IsTestProject() {
+			testProjects = append(testProjects, project)
+		}
+	}
+
+	return testProjects, nil
+}
+
+// findSolutionFile returns the solution (.sln) or solution filter (.slnf) file
+// in the given directory, or an empty string when there is none.
+func findSolutionFile(path string) (string, error) {
+	solutionFiles, err := filepath.Glob(filepath.Join(path, "*.sln"))
 	if err != nil {
 		return "", err
 	}
-	projectFiles = append(projectFiles, fsProjFiles...)
 
-	vbProjFiles, err := filepath.Glob(filepath.Join(path, "*.vbproj"))
+	solutionFilterFiles, err := filepath.Glob(filepath.Join(path, "*.slnf"))
 	if err != nil {
 		return "", err
 	}
-	projectFiles = append(projectFiles, vbProjFiles...)
+	solutionFiles = append(solutionFiles, solutionFilterFiles...)
 
-	if len(projectFiles) > 0 {
-		return projectFiles[0], nil
+	switch len(solutionFiles) {
+	case 0:
+		return "", nil
+	case 1:
+		return solutionFiles[0], nil
+	default:
+		return "", fmt.Errorf("failed to select a solution file: found multiple candidates in %s: %s", path, strings.Join(relativePaths(path, solutionFiles), ", "))
+	}
+}
+
+// selectProjectFile returns the candidate that matches the given project name,
+// or the only candidate when no name is given. Both the file name and the
+// file name without its extension are accepted as the project name.
+func selectProjectFile(dir string, candidates []string, name string) (string, error) {
+	if name != "" {
+		for _, candidate := range candidates {
+			base := filepath.Base(candidate)
+			if base == name || strings.TrimSuffix(base, filepath.Ext(base)) == name {
+				return candidate, nil
+			}
+		}
+
+		return "", fmt.Errorf("failed to find project %q in %s: found %s", name, dir, strings.Join(relativePaths(dir, candidates), ", "))
+	}
+
+	if len(candidates) > 1 {
+		return "", fmt.Errorf("failed to select a project file: found multiple candidates in %s: %s; set BP_DOTNET_PROJECT_NAME to choose one", dir, strings.Join(relativePaths(dir, candidates), ", "))
 	}
 
-	return "", nil
+	return candidates[0], nil
 }
 
-func (p ProjectFileParser) ParseVersion(path, rootDir string) (string, error) {
-	version, found, err := parseVersionFromFile(path, "project file")
+// findStartupProject returns the project referenced by the given solution
+// (.sln) or solution filter (.slnf) file that matches the given project name,
+// or the single executable project when no name is given.
+func (p ProjectFileParser) findStartupProject(solutionPath, rootDir, name string) (string, error) {
+	projects, err := parseSolutionProjects(solutionPath)
 	if err != nil {
 		return "", err
 	}
-	if found {
-		return version, nil
+
+	if len(projects) == 0 {
+		return "", fmt.Errorf("failed to find a project in solution %s", filepath.Base(solutionPath))
 	}
 
-	rootDir = filepath.Clean(rootDir)
-	for dir := filepath.Clean(filepath.Dir(path)); ; dir = filepath.Dir(dir) {
-		propsPath := filepath.Join(dir, "Directory.Build.props")
-		_, err = os.Stat(propsPath)
-		if err != nil && !os.IsNotExist(err) {
+	if name != "" || len(projects) == 1 {
+		return selectProjectFile(filepath.Dir(solutionPath), projects, name)
+	}
+
+	var executables []string
+	for _, project := range projects {
+		model, err := p.ParseProject(project, rootDir, nil)
+		if err != nil {
 			return "", err
 		}
 
-		if err == nil {
-			version, found, err = parseVersionFromFile(propsPath, "Directory.Build.props")
-			if err != nil {
-				return "", err
-			}
-			if found {
-				return version, nil
-			}
+		if model.IsExecutable() {
+			executables = append(executables, project)
 		}
+	}
 
-		if dir == rootDir || filepath.Dir(dir) == dir {
-			break
+	switch len(executables) {
+	case 0:
+		return "", fmt.Errorf("failed to find an executable project in solution %s: none of %s has OutputType Exe or uses the Web SDK", filepath.Base(solutionPath), strings.Join(relativePaths(filepath.Dir(solutionPath), projects), ", "))
+	case 1:
+		return executables[0], nil
+	default:
+		return "", fmt.Errorf("failed to select a startup project in solution %s: found multiple executable projects: %s; set BP_DOTNET_PROJECT_NAME to choose one", filepath.Base(solutionPath), strings.Join(relativePaths(filepath.Dir(solutionPath), executables), ", "))
+	}
+}
+
+// parseSolutionProjects returns the project files referenced by the given
+// solution (.sln) or solution filter (.slnf) file.
+func parseSolutionProjects(path string) ([]string, error) {
+	if filepath.Ext(path) == ".slnf" {
+		return parseSolutionFilter(path)
+	}
+	return parseSolution(path)
+}
+
+// This regular expression matches on project entries of a .sln file, e.g.
+// 'Project("{<type-guid>}") = "<name>", "<path>", "{<project-guid>}"'
+var solutionProjectRe = regexp.MustCompile(`(?m)^\s*Project\("\{[^}]*\}"\)\s*=\s*"[^"]*"\s*,\s*"([^"]+)"`)
+
+func parseSolution(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read solution file: %w", err)
+	}
+
+	var projects []string
+	for _, matches := range solutionProjectRe.FindAllStringSubmatch(string(content), -1) {
+		projectPath := solutionRelativePath(filepath.Dir(path), matches[1])
+
+		// Solution folders are listed as projects whose path is their name,
+		// so only keep the entries that point at a project file.
+		if isProjectFile(projectPath) {
+			projects = append(projects, projectPath)
 		}
 	}
 
-	return "", errors.New("failed to find version in project file: missing or invalid TargetFramework property")
+	return projects, nil
 }
 
-func parseVersionFromFile(path, fileDescription string) (string, bool, error) {
+func parseSolutionFilter(path string) ([]string, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to read %s: %w", fileDescription, err)
+		return nil, fmt.Errorf("failed to read solution filter file: %w", err)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
-	var project struct {
-		PropertyGroups []struct {
-			RuntimeFrameworkVersion string
-			TargetFramework         string
-		} `xml:"PropertyGroup"`
+	var filter struct {
+		Solution struct {
+			Path     string   `json:"path"`
+			Projects []string `json:"projects"`
+		} `json:"solution"`
 	}
 
-	err = xml.NewDecoder(file).Decode(&project)
+	err = json.NewDecoder(file).Decode(&filter)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to parse %s: %w", fileDescription, err)
+		return nil, fmt.Errorf("failed to parse solution filter file: %w", err)
 	}
 
-	for _, group := range project.PropertyGroups {
-		if group.RuntimeFrameworkVersion != "" {
-			return group.RuntimeFrameworkVersion, true, nil
+	// Project paths in a solution filter are relative to the solution it
+	// filters rather than to the filter file itself.
+	solutionDir := filepath.Dir(solutionRelativePath(filepath.Dir(path), filter.Solution.Path))
+
+	var projects []string
+	for _, project := range filter.Solution.Projects {
+		projectPath := solutionRelativePath(solutionDir, project)
+		if isProjectFile(projectPath) {
+			projects = append(projects, projectPath)
 		}
 	}
 
-	// This regular expression matches on 'net<x>.<y>',
-	// 'net<x>.<y>-<platform>' & 'netcoreapp<x>.<y>'
-	targetFrameworkRe := regexp.MustCompile(`net(?:coreapp)?(?:(\d+\.\d)(?:\-?\w+)?)$`)
-	for _, group := range project.PropertyGroups {
-		matches := targetFrameworkRe.FindStringSubmatch(group.TargetFramework)
-		if len(matches) == 2 {
-			return fmt.Sprintf("%s.0", matches[1]), true, nil
+	return projects, nil
+}
+
+// solutionRelativePath converts a path as written in a solution file, which
+// uses Windows separators, into a path relative to the given directory.
+func solutionRelativePath(dir, path string) string {
+	return filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(path, `\`, "/")))
+}
+
+func isProjectFile(path string) bool {
+	for _, extension := range projectFileExtensions {
+		if filepath.Ext(path) == extension {
+			return true
 		}
 	}
+	return false
+}
 
-	return "", false, nil
+func relativePaths(dir string, paths []string) []string {
+	var rel []string
+	for _, path := range paths {
+		r, err := filepath.Rel(dir, path)
+		if err != nil {
+			r = path
+		}
+		rel = append(rel, r)
+	}
+	return rel
 }
 
-func (p ProjectFileParser) NodeIsRequired(path string) (bool, error) {
-	needsNode, err := findInFile("node ", path)
+// ParseProject evaluates the project file at the given path with the given
+// global properties, taking the Directory.Build.props and
+// Directory.Build.targets files up to rootDir into account. The Configuration
+// is Release unless the global properties set it. Projects are only evaluated
+// once per path, root directory and global properties.
+func (p ProjectFileParser) ParseProject(path, rootDir string, globalProperties map[string]string) (ProjectModel, error) {
+	properties := map[string]string{"Configuration": "Release"}
+	for name, value := range globalProperties {
+		if strings.EqualFold(name, "Configuration") {
+			delete(properties, "Configuration")
+		}
+		properties[name] = value
+	}
+
+	key := projectKey{path: path, rootDir: filepath.Clean(rootDir), globalProperties: globalPropertiesKey(properties)}
+	if model, ok := p.projects[key]; ok {
+		return model, nil
+	}
+
+	project, err := internal.EvaluateMSBuildProject(path, rootDir, properties)
 	if err != nil {
-		return false, err
+		return ProjectModel{}, err
+	}
+
+	model := ProjectModel{
+		Path:                    path,
+		SDK:                     strings.TrimSpace(strings.SplitN(project.Sdk, "/", 2)[0]),
+		OutputType:              strings.TrimSpace(project.Properties.Get("OutputType")),
+		RuntimeFrameworkVersion: strings.TrimSpace(project.Properties.Get("RuntimeFrameworkVersion")),
+		TargetFramework:         strings.TrimSpace(project.Properties.Get("TargetFramework")),
+		Properties:              project.Properties,
 	}
 
-	needsNPM, err := findInFile("npm ", path)
+	// The Web and Worker SDKs default the OutputType to Exe.
+	if model.OutputType == "" {
+		model.OutputType = "Library"
+		if model.SDK == "Microsoft.NET.Sdk.Web" || model.SDK == "Microsoft.NET.Sdk.Worker" {
+			model.OutputType = "Exe"
+		}
+	}
+
+	model.InvariantGlobalization, err = invariantGlobalization(project)
 	if err != nil {
-		return false, err
+		return ProjectModel{}, err
+	}
+
+	for _, targetFramework := range strings.Split(project.Properties.Get("TargetFrameworks"), ";") {
+		if targetFramework = strings.TrimSpace(targetFramework); targetFramework != "" {
+			model.TargetFrameworks = append(model.TargetFrameworks, targetFramework)
+		}
 	}
 
-	return needsNode || needsNPM, nil
+	for _, item := range project.Items {
+		model.Items = append(model.Items, ProjectItem(item))
+
+		switch item.Type {
+		case "ProjectReference":
+			model.ProjectReferences = append(model.ProjectReferences, filepath.FromSlash(strings.ReplaceAll(item.Include, `\`, "/")))
+		case "PackageReference":
+			model.PackageReferences = append(model.PackageReferences, PackageReference{Name: item.Include, Version: item.Metadata["Version"]})
+		}
+	}
+
+	for _, target := range project.Targets {
+		projectTarget := ProjectTarget{Name: target.Name}
+		for _, exec := range target.Execs {
+			projectTarget.Execs = append(projectTarget.Execs, ProjectExec(exec))
+		}
+		model.Targets = append(model.Targets, projectTarget)
+	}
+
+	if model.NodeIsRequired() {
+		model.NodeVersion, model.NodeVersionSource, err = findNodeVersion(model)
+		if err != nil {
+			return ProjectModel{}, err
+		}
+	}
+
+	p.projects[key] = model
+
+	return model, nil
 }
 
-func (p ProjectFileParser) NPMIsRequired(path string) (bool, error) {
-	return findInFile("npm ", path)
+// globalPropertiesKey returns a key that is the same for equal sets of global
+// properties, whose names are case-insensitive.
+func globalPropertiesKey(properties map[string]string) string {
+	var pairs []string
+	for name, value := range properties {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", strings.ToLower(name), value))
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "\n")
 }
 
-func findInFile(str, path string) (bool, error) {
+// ParsePublishProfile returns the properties set by the publish profile with
+// the given name, which is the Properties/PublishProfiles/<name>.pubxml file
+// next to the project file at the given path. Like the project file, the
+// profile is evaluated, but only the properties it declares are returned.
+func (p ProjectFileParser) ParsePublishProfile(path, name string) (PublishProfile, error) {
+	name = strings.TrimSuffix(name, ".pubxml")
+	profilePath := filepath.Join(filepath.Dir(path), "Properties", "PublishProfiles", name+".pubxml")
+
+	content, err := os.ReadFile(profilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return PublishProfile{}, fmt.Errorf("failed to find publish profile %q: %s does not exist", name, profilePath)
+		}
+		return PublishProfile{}, fmt.Errorf("failed to read publish profile %q: %w", name, err)
+	}
+
+	declared, err := declaredProperties(content)
+	if err != nil {
+		return PublishProfile{}, fmt.Errorf("failed to parse publish profile %q: %w", name, err)
+	}
+
+	profile, err := internal.EvaluateMSBuildProject(profilePath, filepath.Dir(profilePath), map[string]string{
+		"ImportDirectoryBuildProps":   "false",
+		"ImportDirectoryBuildTargets": "false",
+	})
+	if err != nil {
+		return PublishProfile{}, fmt.Errorf("failed to parse publish profile %q: %w", name, err)
+	}
+
+	properties := map[string]string{}
+	for _, property := range declared {
+		if profile.Properties.Has(property) {
+			properties[strings.ToLower(property)] = strings.TrimSpace(profile.Properties.Get(property))
+		}
+	}
+
+	return PublishProfile{
+		Name:       name,
+		Path:       profilePath,
+		Properties: properties,
+	}, nil
+}
+
+// declaredProperties returns the names of the properties that the property
+// groups of an MSBuild file declare.
+func declaredProperties(content []byte) ([]string, error) {
+	var (
+		names    []string
+		elements []string
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if len(elements) > 0 && elements[len(elements)-1] == "PropertyGroup" {
+				names = append(names, element.Name.Local)
+			}
+			elements = append(elements, element.Name.Local)
+		case xml.EndElement:
+			elements = elements[:len(elements)-1]
+		}
+	}
+}
+
+// invariantGlobalization reads the InvariantGlobalization property, falling
+// back to the System.Globalization.Invariant setting of the
+// runtimeconfig.template.json file of the project.
+func invariantGlobalization(project internal.MSBuildProject) (bool, error) {
+	if value := strings.TrimSpace(project.Properties.Get("InvariantGlobalization")); value != "" {
+		return strings.EqualFold(value, "true"), nil
+	}
+
+	path := project.Properties.Get("UserRuntimeConfig")
+	if path == "" {
+		path = filepath.Join(filepath.Dir(project.Path), "runtimeconfig.template.json")
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(project.Path), path)
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
-		return false, fmt.Errorf("failed to open %s: %w", path, err)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read runtimeconfig.template.json: %w", err)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
-	var project struct {
-		Targets []struct {
-			Execs []struct {
-				Command string `xml:",attr"`
-			} `xml:"Exec"`
-		} `xml:"Target"`
+	var template struct {
+		ConfigProperties map[string]interface{} `json:"configProperties"`
 	}
 
-	err = xml.NewDecoder(file).Decode(&project)
+	err = json.NewDecoder(file).Decode(&template)
 	if err != nil {
-		return false, fmt.Errorf("failed to decode %s: %w", path, err)
+		return false, fmt.Errorf("failed to parse runtimeconfig.template.json: %w", err)
 	}
 
-	for _, target := range project.Targets {
-		for _, exec := range target.Execs {
-			if strings.HasPrefix(exec.Command, str) {
-				return true, nil
+	switch value := template.ConfigProperties["System.Globalization.Invariant"].(type) {
+	case bool:
+		return value, nil
+	case string:
+		return strings.EqualFold(value, "true"), nil
+	}
+
+	return false, nil
+}
+
+// This regular expression matches on the aliases of node version managers,
+// e.g. lts/*, lts/iron, node or stable, which start with a letter unlike
+// versions and ranges.
+var nodeVersionAliasRe = regexp.MustCompile(`^[A-Za-z]`)
+
+// findNodeVersion returns the node version constraint of the single page
+// application of the project, along with the file it was read from. The app is
+// expected in the directory given by the SpaRoot property or by the
+// WorkingDirectory of the Exec tasks that run node, and defaults to the project
+// directory. As in the node-engine buildpack, package.json takes precedence
+// over .nvmrc and .node-version.
+func findNodeVersion(project ProjectModel) (string, string, error) {
+	spaRoot := project.Property("SpaRoot")
+	if spaRoot == "" {
+		for _, target := range project.Targets {
+			for _, exec := range target.Execs {
+				if spaRoot == "" && exec.WorkingDirectory != "" && exec.runsProgram(nodePrograms...) {
+					spaRoot = exec.WorkingDirectory
+				}
 			}
 		}
 	}
 
-	return false, nil
+	dir := filepath.FromSlash(strings.ReplaceAll(strings.TrimSpace(spaRoot), `\`, "/"))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(project.Path), dir)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", "", fmt.Errorf("failed to read package.json: %w", err)
+	}
+
+	if err == nil {
+		var packageJSON struct {
+			Engines struct {
+				Node string `json:"node"`
+			} `json:"engines"`
+		}
+
+		err = json.Unmarshal(content, &packageJSON)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse package.json: %w", err)
+		}
+
+		// The range is passed on as is: it follows the npm syntax, which the
+		// node-engine buildpack resolves.
+		if version := strings.TrimSpace(packageJSON.Engines.Node); version != "" {
+			return version, "package.json", nil
+		}
+	}
+
+	for _, file := range []string{".nvmrc", ".node-version"} {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return "", "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		version := strings.TrimPrefix(strings.TrimSpace(string(content)), "v")
+
+		// Aliases such as lts/* or node can only be resolved by a version
+		// manager, so they do not constrain the version.
+		if version == "" || nodeVersionAliasRe.MatchString(version) {
+			continue
+		}
+
+		return version, file, nil
+	}
+
+	return "", "", nil
+}
+
+// ParseGlobalJSON returns the SDK settings of the closest global.json file,
+// looking in the directory of the project file and its parents up to rootDir.
+func (p ProjectFileParser) ParseGlobalJSON(path, rootDir string) (GlobalJSON, error) {
+	rootDir = filepath.Clean(rootDir)
+	for dir := filepath.Clean(filepath.Dir(path)); ; dir = filepath.Dir(dir) {
+		globalJSONPath := filepath.Join(dir, "global.json")
+		_, err := os.Stat(globalJSONPath)
+		if err != nil && !os.IsNotExist(err) {
+			return GlobalJSON{}, err
+		}
+
+		if err == nil {
+			return parseGlobalJSONFile(globalJSONPath)
+		}
+
+		if dir == rootDir || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	return GlobalJSON{}, nil
+}
+
+// parseGlobalJSONFile parses the global.json file at the given path. Like the
+// .NET host, it accepts comments and trailing commas.
+func parseGlobalJSONFile(path string) (GlobalJSON, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return GlobalJSON{}, fmt.Errorf("failed to read global.json: %w", err)
+	}
+
+	var globalJSON GlobalJSON
+	err = json.Unmarshal(standardizeJSON(content), &globalJSON)
+	if err != nil {
+		return GlobalJSON{}, fmt.Errorf("failed to parse global.json: %w", err)
+	}
+
+	err = globalJSON.validate()
+	if err != nil {
+		return GlobalJSON{}, fmt.Errorf("failed to parse global.json: %w", err)
+	}
+
+	globalJSON.Path = path
+	return globalJSON, nil
 }
@@ -33,7 +33,7 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("returns an empty string and no error", func() {
-			projectFilePath, err := parser.FindProjectFile(path)
+			projectFilePath, err := parser.FindProjectFile(path, path, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(projectFilePath).To(Equal(""))
 		})
@@ -44,7 +44,7 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the path to it", func() {
-				projectFilePath, err := parser.FindProjectFile(path)
+				projectFilePath, err := parser.FindProjectFile(path, path, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(projectFilePath).To(Equal(filepath.Join(path, "app.csproj")))
 			})
@@ -56,7 +56,7 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the path to it", func() {
-				projectFilePath, err := parser.FindProjectFile(path)
+				projectFilePath, err := parser.FindProjectFile(path, path, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(projectFilePath).To(Equal(filepath.Join(path, "app.fsproj")))
 			})
@@ -68,24 +68,376 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the path to it", func() {
-				projectFilePath, err := parser.FindProjectFile(path)
+				projectFilePath, err := parser.FindProjectFile(path, path, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(projectFilePath).To(Equal(filepath.Join(path, "app.vbproj")))
 			})
 		})
 
+		context("when there are multiple project files", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(path, "app.csproj"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(path, "other.fsproj"), nil, 0600)).To(Succeed())
+			})
+
+			it("returns an error listing the candidates", func() {
+				_, err := parser.FindProjectFile(path, path, "")
+				Expect(err).To(MatchError(ContainSubstring("failed to select a project file: found multiple candidates")))
+				Expect(err).To(MatchError(ContainSubstring("app.csproj, other.fsproj; set BP_DOTNET_PROJECT_NAME to choose one")))
+			})
+
+			context("when a project name is given", func() {
+				it("returns the path to the named project", func() {
+					projectFilePath, err := parser.FindProjectFile(path, path, "other")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(path, "other.fsproj")))
+				})
+			})
+
+			context("when a project file name is given", func() {
+				it("returns the path to the named project", func() {
+					projectFilePath, err := parser.FindProjectFile(path, path, "app.csproj")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(path, "app.csproj")))
+				})
+			})
+
+			context("when the given project name does not match any project", func() {
+				it("returns an error listing the candidates", func() {
+					_, err := parser.FindProjectFile(path, path, "missing")
+					Expect(err).To(MatchError(ContainSubstring(`failed to find project "missing"`)))
+					Expect(err).To(MatchError(ContainSubstring("found app.csproj, other.fsproj")))
+				})
+			})
+		})
+
+		context("when there is a solution file", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(path, "console"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(path, "console", "console.csproj"), []byte(`
+					<Project Sdk="Microsoft.NET.Sdk">
+					  <PropertyGroup>
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+
+				Expect(os.MkdirAll(filepath.Join(path, "web"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(path, "web", "web.csproj"), []byte(`
+					<Project Sdk="Microsoft.NET.Sdk.Web">
+					  <PropertyGroup>
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+
+				Expect(os.WriteFile(filepath.Join(path, "app.sln"), []byte(`
+Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "src", "src", "{1D1C3E5B-0F0B-4C67-8E38-62F0F4D3A0C1}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "console", "console\console.csproj", "{3AAB682F-AA14-4C93-BB12-EA3373F34BB0}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "web", "web\web.csproj", "{8B994D0D-724C-47FF-B661-CEEE6BC368EB}"
+EndProject
+`), 0600)).To(Succeed())
+			})
+
+			it("returns the path to the executable project", func() {
+				projectFilePath, err := parser.FindProjectFile(path, path, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(projectFilePath).To(Equal(filepath.Join(path, "web", "web.csproj")))
+			})
+
+			context("when the executable project sets OutputType", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(path, "web", "web.csproj"), []byte(`
+						<Project Sdk="Microsoft.NET.Sdk">
+						  <PropertyGroup>
+						    <OutputType>Exe</OutputType>
+						  </PropertyGroup>
+						</Project>
+					`), 0600)).To(Succeed())
+				})
+
+				it("returns the path to it", func() {
+					projectFilePath, err := parser.FindProjectFile(path, path, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(path, "web", "web.csproj")))
+				})
+			})
+
+			context("when the solution is below the root and OutputType is set in the root Directory.Build.props", func() {
+				var solutionDir string
+
+				it.Before(func() {
+					solutionDir = filepath.Join(path, "src")
+					Expect(os.MkdirAll(solutionDir, os.ModePerm)).To(Succeed())
+					for _, entry := range []string{"console", "web", "app.sln"} {
+						Expect(os.Rename(filepath.Join(path, entry), filepath.Join(solutionDir, entry))).To(Succeed())
+					}
+
+					Expect(os.WriteFile(filepath.Join(solutionDir, "web", "web.csproj"), []byte(`
+						<Project Sdk="Microsoft.NET.Sdk">
+						  <PropertyGroup>
+						    <TargetFramework>net8.0</TargetFramework>
+						  </PropertyGroup>
+						</Project>
+					`), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(path, "Directory.Build.props"), []byte(`
+						<Project>
+						  <PropertyGroup Condition="'$(MSBuildProjectName)' == 'web'">
+						    <OutputType>Exe</OutputType>
+						  </PropertyGroup>
+						</Project>
+					`), 0600)).To(Succeed())
+				})
+
+				it("evaluates the projects up to the root", func() {
+					projectFilePath, err := parser.FindProjectFile(solutionDir, path, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(solutionDir, "web", "web.csproj")))
+				})
+			})
+
+			context("when a project name is given", func() {
+				it("returns the path to the named project", func() {
+					projectFilePath, err := parser.FindProjectFile(path, path, "console")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(path, "console", "console.csproj")))
+				})
+			})
+
+			context("when the solution only references one project", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(path, "app.sln"), []byte(`
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "console", "console\console.csproj", "{3AAB682F-AA14-4C93-BB12-EA3373F34BB0}"
+EndProject
+`), 0600)).To(Succeed())
+				})
+
+				it("returns the path to it", func() {
+					projectFilePath, err := parser.FindProjectFile(path, path, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(path, "console", "console.csproj")))
+				})
+			})
+
+			context("when there is also a project file in the directory", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(path, "app.csproj"), nil, 0600)).To(Succeed())
+				})
+
+				it("returns the path to the project file", func() {
+					projectFilePath, err := parser.FindProjectFile(path, path, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(projectFilePath).To(Equal(filepath.Join(path, "app.csproj")))
+				})
+			})
+		})
+
+		context("when there is a solution filter file", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(path, "src", "api"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(path, "src", "api", "api.csproj"), []byte(`
+					<Project Sdk="Microsoft.NET.Sdk.Web">
+					</Project>
+				`), 0600)).To(Succeed())
+
+				Expect(os.MkdirAll(filepath.Join(path, "filters"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(path, "filters", "api.slnf"), []byte(`{
+					"solution": {
+						"path": "..\\app.sln",
+						"projects": [
+							"src\\api\\api.csproj"
+						]
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("returns the path to the project relative to the filtered solution", func() {
+				projectFilePath, err := parser.FindProjectFile(filepath.Join(path, "filters"), path, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(projectFilePath).To(Equal(filepath.Join(path, "src", "api", "api.csproj")))
+			})
+		})
+
 		context("failure cases", func() {
 			context("when file pattern matching fails", func() {
 				it("returns the error", func() {
-					_, err := parser.FindProjectFile(`\`)
+					_, err := parser.FindProjectFile(`\`, path, "")
 					Expect(err).To(MatchError("syntax error in pattern"))
 				})
 			})
 
+			context("when there are multiple solution files", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(path, "first.sln"), nil, 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(path, "second.sln"), nil, 0600)).To(Succeed())
+				})
+
+				it("returns an error listing the candidates", func() {
+					_, err := parser.FindProjectFile(path, path, "")
+					Expect(err).To(MatchError(ContainSubstring("failed to select a solution file: found multiple candidates")))
+					Expect(err).To(MatchError(ContainSubstring("first.sln, second.sln")))
+				})
+			})
+
+			context("when a solution has multiple executable projects", func() {
+				it.Before(func() {
+					for _, name := range []string{"first", "second"} {
+						Expect(os.MkdirAll(filepath.Join(path, name), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(path, name, name+".csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk.Web"></Project>`), 0600)).To(Succeed())
+					}
+
+					Expect(os.WriteFile(filepath.Join(path, "app.sln"), []byte(`
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "first", "first\first.csproj", "{3AAB682F-AA14-4C93-BB12-EA3373F34BB0}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "second", "second\second.csproj", "{8B994D0D-724C-47FF-B661-CEEE6BC368EB}"
+EndProject
+`), 0600)).To(Succeed())
+				})
+
+				it("returns an error listing the candidates", func() {
+					_, err := parser.FindProjectFile(path, path, "")
+					Expect(err).To(MatchError(ContainSubstring("failed to select a startup project in solution app.sln: found multiple executable projects")))
+					Expect(err).To(MatchError(ContainSubstring(filepath.Join("first", "first.csproj") + ", " + filepath.Join("second", "second.csproj"))))
+				})
+			})
+
+			context("when a solution has no executable projects", func() {
+				it.Before(func() {
+					for _, name := range []string{"first", "second"} {
+						Expect(os.MkdirAll(filepath.Join(path, name), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(path, name, name+".csproj"), []byte(`<Project Sdk="Microsoft.NET.Sdk"></Project>`), 0600)).To(Succeed())
+					}
+
+					Expect(os.WriteFile(filepath.Join(path, "app.sln"), []byte(`
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "first", "first\first.csproj", "{3AAB682F-AA14-4C93-BB12-EA3373F34BB0}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "second", "second\second.csproj", "{8B994D0D-724C-47FF-B661-CEEE6BC368EB}"
+EndProject
+`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.FindProjectFile(path, path, "")
+					Expect(err).To(MatchError(ContainSubstring("failed to find an executable project in solution app.sln")))
+				})
+			})
+
+			context("when a solution references a project that does not exist", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(path, "app.sln"), []byte(`
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "first", "first\first.csproj", "{3AAB682F-AA14-4C93-BB12-EA3373F34BB0}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "second", "second\second.csproj", "{8B994D0D-724C-47FF-B661-CEEE6BC368EB}"
+EndProject
+`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.FindProjectFile(path, path, "")
+					Expect(err).To(MatchError(ContainSubstring("failed to read project file")))
+				})
+			})
+
+			context("when a solution filter cannot be parsed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(path, "app.slnf"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.FindProjectFile(path, path, "")
+					Expect(err).To(MatchError(ContainSubstring("failed to parse solution filter file")))
+				})
+			})
+		})
+	})
+
+	context("FindTestProjects", func() {
+		var path string
+
+		it.Before(func() {
+			var err error
+			path, err = os.MkdirTemp("", "workingDir")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, project := range []string{"web", "unit-tests", "integration-tests"} {
+				Expect(os.MkdirAll(filepath.Join(path, project), os.ModePerm)).To(Succeed())
+			}
+
+			Expect(os.WriteFile(filepath.Join(path, "web", "web.csproj"), []byte(`
+				<Project Sdk="Microsoft.NET.Sdk.Web">
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(path, "unit-tests", "unit-tests.csproj"), []byte(`
+				<Project Sdk="Microsoft.NET.Sdk">
+				  <ItemGroup>
+				    <PackageReference Include="Microsoft.NET.Test.Sdk" Version="17.8.0" />
+				  </ItemGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(path, "integration-tests", "integration-tests.csproj"), []byte(`
+				<Project Sdk="Microsoft.NET.Sdk">
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(path, "integration-tests", "Directory.Build.props"), []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <IsTestProject>true</IsTestProject>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(path, "app.sln"), []byte(`
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "web", "web\web.csproj", "{8B994D0D-724C-47FF-B661-CEEE6BC368EB}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "unit-tests", "unit-tests\unit-tests.csproj", "{3AAB682F-AA14-4C93-BB12-EA3373F34BB0}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "integration-tests", "integration-tests\integration-tests.csproj", "{1D1C3E5B-0F0B-4C67-8E38-62F0F4D3A0C1}"
+EndProject
+`), 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(path)).To(Succeed())
+		})
+
+		it("returns the test projects of the solution", func() {
+			projects, err := parser.FindTestProjects(path, path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(Equal([]string{
+				filepath.Join(path, "unit-tests", "unit-tests.csproj"),
+				filepath.Join(path, "integration-tests", "integration-tests.csproj"),
+			}))
+		})
+
+		context("when the directory contains a project file", func() {
+			it("returns no projects", func() {
+				projects, err := parser.FindTestProjects(filepath.Join(path, "web"), path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(projects).To(BeEmpty())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when a solution references a project that does not exist", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(filepath.Join(path, "unit-tests"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.FindTestProjects(path, path)
+					Expect(err).To(HaveOccurred())
+				})
+			})
 		})
 	})
 
-	context("ParseVersion", func() {
+	context("ParseProject", func() {
 		var (
 			path string
 			root string
@@ -118,7 +470,10 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("1.2.3"))
@@ -138,7 +493,10 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns the version", func() {
-					version, err := parser.ParseVersion(path, root)
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					version, err := project.RuntimeVersion("")
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(version).To(Equal(tf[3:] + ".0"))
@@ -158,7 +516,10 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("1.2.0"))
@@ -177,13 +538,50 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("1.2.0"))
 			})
 		})
 
+		context("when TargetFrameworks is set", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`
+					<Project>
+					  <PropertyGroup>
+					    <TargetFrameworks>net6.0; net8.0-windows;net8.0;netstandard2.0</TargetFrameworks>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+			})
+
+			it("returns the version of the highest supported framework", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(version).To(Equal("8.0.0"))
+			})
+
+			context("when a framework is requested", func() {
+				it("returns the version of the requested framework", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					version, err := project.RuntimeVersion("net6.0")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(version).To(Equal("6.0.0"))
+				})
+			})
+		})
+
 		context("when TargetFramework is set in Directory.Build.props in the same directory", func() {
 			var propsPath string
 
@@ -207,7 +605,10 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("8.0.0"))
@@ -241,11 +642,29 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("9.0.0"))
 			})
+
+			context("when the project was parsed with a narrower root before", func() {
+				it("evaluates it again for the wider root", func() {
+					_, err := parser.ParseProject(path, filepath.Dir(path), nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					version, err := project.RuntimeVersion("")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(version).To(Equal("9.0.0"))
+				})
+			})
 		})
 
 		context("when RuntimeFrameworkVersion is set in Directory.Build.props", func() {
@@ -271,7 +690,10 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("1.2.3"))
@@ -297,133 +719,376 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the project file version", func() {
-				version, err := parser.ParseVersion(path, root)
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(version).To(Equal("8.0.0"))
 			})
 		})
 
-		context("failure cases", func() {
-			context("when the file can not be opened", func() {
-				it.Before(func() {
-					Expect(os.RemoveAll(path)).To(Succeed())
-				})
+		context("when TargetFramework is set in an imported file through a property", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(root, "build"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "build", "versions.props"), []byte(`
+					<Project>
+					  <PropertyGroup>
+					    <NetVersion>9.0</NetVersion>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
 
-				it("errors", func() {
-					_, err := parser.ParseVersion(path, root)
-					Expect(err.Error()).To(ContainSubstring("failed to read project file"))
-				})
+				Expect(os.WriteFile(path, []byte(`
+					<Project>
+					  <Import Project="$(MSBuildThisFileDirectory)build\versions.props" />
+					  <PropertyGroup>
+					    <TargetFramework>net$(NetVersion)</TargetFramework>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
 			})
 
-			context("when the file can not be decoded", func() {
-				it.Before(func() {
-					Expect(os.WriteFile(path, []byte("%%%"), 0644)).To(Succeed())
-				})
+			it("returns the version", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
 
-				it("errors", func() {
-					_, err := parser.ParseVersion(path, root)
-					Expect(err.Error()).To(ContainSubstring("failed to parse project file"))
-				})
+				version, err := project.RuntimeVersion("")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(version).To(Equal("9.0.0"))
 			})
+		})
 
-			context("when the file can not be decoded", func() {
-				it.Before(func() {
-					Expect(os.WriteFile(path, []byte(`
+		context("when TargetFramework is set conditionally", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`
 					<Project>
-					  <PropertyGroup>
-              <TargetFramework>bad content</TargetFramework>
-            </PropertyGroup>
+					  <PropertyGroup Condition="'$(Configuration)' == 'Debug'">
+					    <TargetFramework>net6.0</TargetFramework>
+					  </PropertyGroup>
+					  <PropertyGroup Condition="'$(Configuration)' == 'Release'">
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
 					</Project>
 				`), 0600)).To(Succeed())
-				})
+			})
 
-				it("errors", func() {
-					_, err := parser.ParseVersion(path, root)
-					Expect(err.Error()).To(ContainSubstring("failed to find version in project file: missing or invalid TargetFramework property"))
-				})
+			it("returns the version of the matching property group", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				version, err := project.RuntimeVersion("")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(version).To(Equal("8.0.0"))
 			})
 		})
-	})
-
-	context("NodeIsRequired", func() {
-		var path string
 
-		it.Before(func() {
-			file, err := os.CreateTemp("", "app.csproj")
+		it("returns the evaluated project", func() {
+			Expect(os.WriteFile(path, []byte(`
+				<Project Sdk="Microsoft.NET.Sdk.Web/1.0.0">
+				  <PropertyGroup>
+				    <TargetFramework>net8.0</TargetFramework>
+				    <SpaRoot>ClientApp/</SpaRoot>
+				  </PropertyGroup>
+				  <ItemGroup>
+				    <PackageReference Include="Newtonsoft.Json" Version="13.0.3" />
+				    <PackageReference Include="Serilog">
+				      <Version>3.1.1</Version>
+				    </PackageReference>
+				    <ProjectReference Include="..\lib\lib.csproj" />
+				    <Content Remove="$(SpaRoot)**" />
+				  </ItemGroup>
+				  <Target Name="NpmInstall" BeforeTargets="Build">
+				    <Exec WorkingDirectory="$(SpaRoot)" Command="npm install" />
+				  </Target>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			project, err := parser.ParseProject(path, root, nil)
 			Expect(err).NotTo(HaveOccurred())
 
-			path = file.Name()
-			Expect(file.Close()).To(Succeed())
+			Expect(project.Path).To(Equal(path))
+			Expect(project.SDK).To(Equal("Microsoft.NET.Sdk.Web"))
+			Expect(project.OutputType).To(Equal("Exe"))
+			Expect(project.TargetFramework).To(Equal("net8.0"))
+			Expect(project.TargetFrameworks).To(BeEmpty())
+			Expect(project.Property("sparoot")).To(Equal("ClientApp/"))
+			Expect(project.PackageReferences).To(Equal([]dotnetpublish.PackageReference{
+				{Name: "Newtonsoft.Json", Version: "13.0.3"},
+				{Name: "Serilog", Version: "3.1.1"},
+			}))
+			Expect(project.ProjectReferences).To(Equal([]string{filepath.Join("..", "lib", "lib.csproj")}))
+			Expect(project.Targets).To(Equal([]dotnetpublish.ProjectTarget{
+				{Name: "NpmInstall", Execs: []dotnetpublish.ProjectExec{{Command: "npm install", WorkingDirectory: "ClientApp/"}}},
+			}))
 		})
 
-		it.After(func() {
-			Expect(os.RemoveAll(path)).To(Succeed())
+		it("only evaluates a project once", func() {
+			Expect(os.WriteFile(path, []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <TargetFramework>net8.0</TargetFramework>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			project, err := parser.ParseProject(path, root, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project.OutputType).To(Equal("Library"))
+
+			Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+
+			cached, err := parser.ParseProject(path, root, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached).To(Equal(project))
 		})
 
-		context("when project includes target commands that invoke node", func() {
+		context("when a PropertyGroup depends on the configuration and platform", func() {
 			it.Before(func() {
 				Expect(os.WriteFile(path, []byte(`
 					<Project>
-						<Target Name="first-target">
-							<Exec Command="echo hello" />
-						</Target>
-						<Target Name="second-target">
-							<Exec Command="node --version" />
-						</Target>
+					  <PropertyGroup>
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
+					  <PropertyGroup Condition="'$(Configuration)|$(Platform)'=='Release|AnyCPU'">
+					    <RuntimeIdentifier>linux-x64</RuntimeIdentifier>
+					    <InvariantGlobalization>true</InvariantGlobalization>
+					  </PropertyGroup>
 					</Project>
 				`), 0600)).To(Succeed())
 			})
 
-			it("returns true", func() {
-				needNode, err := parser.NodeIsRequired(path)
+			it("evaluates the project for the AnyCPU platform by default", func() {
+				project, err := parser.ParseProject(path, root, nil)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(project.Property("RuntimeIdentifier")).To(Equal("linux-x64"))
+				Expect(project.InvariantGlobalization).To(BeTrue())
+			})
 
-				Expect(needNode).To(BeTrue())
+			it("evaluates the project for the platform of the global properties", func() {
+				project, err := parser.ParseProject(path, root, map[string]string{"Platform": "x64"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(project.Property("RuntimeIdentifier")).To(BeEmpty())
+				Expect(project.InvariantGlobalization).To(BeFalse())
 			})
 		})
 
-		context("when project does NOT include target commands that invoke node", func() {
+		context("when a PropertyGroup depends on the configuration", func() {
 			it.Before(func() {
 				Expect(os.WriteFile(path, []byte(`
 					<Project>
-						<Target Name="first-target">
-							<Exec Command="echo hello" />
-						</Target>
-						<Target Name="second-target">
-							<Exec Command="echo goodbye" />
-						</Target>
+					  <PropertyGroup>
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
+					  <PropertyGroup Condition="'$(Configuration)' == 'Debug'">
+					    <InvariantGlobalization>true</InvariantGlobalization>
+					  </PropertyGroup>
 					</Project>
 				`), 0600)).To(Succeed())
 			})
 
-			it("returns false", func() {
-				needNode, err := parser.NodeIsRequired(path)
+			it("evaluates the project for the Release configuration by default", func() {
+				project, err := parser.ParseProject(path, root, nil)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(project.InvariantGlobalization).To(BeFalse())
+			})
+
+			it("evaluates the project for the configuration of the global properties", func() {
+				project, err := parser.ParseProject(path, root, map[string]string{"configuration": "Debug"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(project.InvariantGlobalization).To(BeTrue())
+
+				project, err = parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(project.InvariantGlobalization).To(BeFalse())
+			})
+		})
+
+		context("when InvariantGlobalization is set in Directory.Build.props", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`<Project></Project>`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "Directory.Build.props"), []byte(`
+					<Project>
+					  <PropertyGroup>
+					    <InvariantGlobalization>true</InvariantGlobalization>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+			})
 
-				Expect(needNode).To(BeFalse())
+			it("reports invariant globalization", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(project.InvariantGlobalization).To(BeTrue())
 			})
 		})
 
-		context("when project includes target commands that invoke npm", func() {
+		context("when invariant globalization is set in runtimeconfig.template.json", func() {
 			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`<Project></Project>`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "runtimeconfig.template.json"), []byte(`{
+					"configProperties": {
+						"System.Globalization.Invariant": true
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("reports invariant globalization", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(project.InvariantGlobalization).To(BeTrue())
+			})
+
+			context("when the project sets InvariantGlobalization to false", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`
+						<Project>
+						  <PropertyGroup>
+						    <InvariantGlobalization>false</InvariantGlobalization>
+						  </PropertyGroup>
+						</Project>
+					`), 0600)).To(Succeed())
+				})
+
+				it("prefers the project property", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(project.InvariantGlobalization).To(BeFalse())
+				})
+			})
+		})
+
+		context("when the project builds a single page application", func() {
+			var spaRoot string
+
+			it.Before(func() {
+				spaRoot = filepath.Join(root, "ClientApp")
+				Expect(os.MkdirAll(spaRoot, os.ModePerm)).To(Succeed())
+
 				Expect(os.WriteFile(path, []byte(`
 					<Project>
-						<Target Name="first-target">
-							<Exec Command="echo hello" />
-						</Target>
-						<Target Name="second-target">
-							<Exec Command="npm install" />
-						</Target>
+					  <PropertyGroup>
+					    <SpaRoot>ClientApp\</SpaRoot>
+					  </PropertyGroup>
+					  <Target Name="PublishRunWebpack">
+					    <Exec WorkingDirectory="$(SpaRoot)" Command="npm ci" />
+					  </Target>
 					</Project>
 				`), 0600)).To(Succeed())
 			})
 
-			it("returns true", func() {
-				needNode, err := parser.NodeIsRequired(path)
+			it("returns no node version when the app does not constrain it", func() {
+				project, err := parser.ParseProject(path, root, nil)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(project.NodeVersion).To(Equal(""))
+				Expect(project.NodeVersionSource).To(Equal(""))
+			})
+
+			context("when package.json sets engines.node", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(spaRoot, "package.json"), []byte(`{"engines": {"node": "^16.14.0"}}`), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(spaRoot, ".nvmrc"), []byte("18"), 0600)).To(Succeed())
+				})
+
+				it("returns the engines.node constraint", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(project.NodeVersion).To(Equal("^16.14.0"))
+					Expect(project.NodeVersionSource).To(Equal("package.json"))
+				})
+
+				for _, constraint := range []string{">=14.0.0 <16", "^16 || ^18", ">=16.14.0 <17 || >=18"} {
+					context("when engines.node is the range "+constraint, func() {
+						it.Before(func() {
+							Expect(os.WriteFile(filepath.Join(spaRoot, "package.json"), []byte(`{"engines": {"node": "`+constraint+`"}}`), 0600)).To(Succeed())
+						})
+
+						it("returns the range as is", func() {
+							project, err := parser.ParseProject(path, root, nil)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(project.NodeVersion).To(Equal(constraint))
+							Expect(project.NodeVersionSource).To(Equal("package.json"))
+						})
+					})
+				}
+			})
+
+			context("when there is a .nvmrc file", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(spaRoot, "package.json"), []byte(`{"name": "client-app"}`), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(spaRoot, ".nvmrc"), []byte("v18.17.1\n"), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(spaRoot, ".node-version"), []byte("20"), 0600)).To(Succeed())
+				})
+
+				it("returns its version", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(project.NodeVersion).To(Equal("18.17.1"))
+					Expect(project.NodeVersionSource).To(Equal(".nvmrc"))
+				})
+
+				context("when it contains a range", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(spaRoot, ".nvmrc"), []byte(">=18.0.0 <20\n"), 0600)).To(Succeed())
+					})
+
+					it("returns the range", func() {
+						project, err := parser.ParseProject(path, root, nil)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(project.NodeVersion).To(Equal(">=18.0.0 <20"))
+						Expect(project.NodeVersionSource).To(Equal(".nvmrc"))
+					})
+				})
 
-				Expect(needNode).To(BeTrue())
+				context("when it contains an alias", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(spaRoot, ".nvmrc"), []byte("lts/*"), 0600)).To(Succeed())
+					})
+
+					it("falls back to the .node-version file", func() {
+						project, err := parser.ParseProject(path, root, nil)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(project.NodeVersion).To(Equal("20"))
+						Expect(project.NodeVersionSource).To(Equal(".node-version"))
+					})
+				})
+			})
+
+			context("when the app is located by the Exec working directory", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`
+						<Project>
+						  <Target Name="BuildFrontend">
+						    <Exec Command="dotnet tool restore" />
+						    <Exec WorkingDirectory="ClientApp" Command="yarn build" />
+						  </Target>
+						</Project>
+					`), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(spaRoot, ".node-version"), []byte("20.11.0"), 0600)).To(Succeed())
+				})
+
+				it("returns the version of that directory", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(project.NodeVersion).To(Equal("20.11.0"))
+					Expect(project.NodeVersionSource).To(Equal(".node-version"))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when package.json can not be decoded", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(spaRoot, "package.json"), []byte("%%%"), 0600)).To(Succeed())
+					})
+
+					it("errors", func() {
+						_, err := parser.ParseProject(path, root, nil)
+						Expect(err).To(MatchError(ContainSubstring("failed to parse package.json")))
+					})
+				})
 			})
 		})
 
@@ -434,8 +1099,20 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("errors", func() {
-					_, err := parser.NodeIsRequired(path)
-					Expect(err.Error()).To(ContainSubstring("failed to open"))
+					_, err := parser.ParseProject(path, root, nil)
+					Expect(err.Error()).To(ContainSubstring("failed to read project file"))
+				})
+			})
+
+			context("when runtimeconfig.template.json can not be decoded", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`<Project></Project>`), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(root, "runtimeconfig.template.json"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.ParseProject(path, root, nil)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse runtimeconfig.template.json")))
 				})
 			})
 
@@ -445,71 +1122,377 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("errors", func() {
-					_, err := parser.NodeIsRequired(path)
-					Expect(err.Error()).To(ContainSubstring("failed to decode"))
+					_, err := parser.ParseProject(path, root, nil)
+					Expect(err.Error()).To(ContainSubstring("failed to parse project file"))
+				})
+			})
+
+			context("when the requested framework is not targeted by the project", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`
+						<Project>
+						  <PropertyGroup>
+						    <TargetFrameworks>net6.0;net8.0</TargetFrameworks>
+						  </PropertyGroup>
+						</Project>
+					`), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = project.RuntimeVersion("net9.0")
+					Expect(err).To(MatchError(`failed to select target framework "net9.0": project targets net6.0, net8.0`))
+				})
+			})
+
+			context("when none of the target frameworks is supported", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`
+						<Project>
+						  <PropertyGroup>
+						    <TargetFrameworks>net8.0-android;net8.0-ios</TargetFrameworks>
+						  </PropertyGroup>
+						</Project>
+					`), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = project.RuntimeVersion("")
+					Expect(err).To(MatchError("failed to select target framework: none of net8.0-android, net8.0-ios is supported"))
 				})
 			})
 
+			context("when the file can not be decoded", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte(`
+					<Project>
+					  <PropertyGroup>
+              <TargetFramework>bad content</TargetFramework>
+            </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = project.RuntimeVersion("")
+					Expect(err.Error()).To(ContainSubstring("failed to find version in project file: missing or invalid TargetFramework property"))
+				})
+			})
 		})
 	})
 
-	context("NPMIsRequired", func() {
-		var path string
+	context("PublishFramework", func() {
+		var (
+			path string
+			root string
+		)
 
 		it.Before(func() {
-			file, err := os.CreateTemp("", "app.csproj")
+			var err error
+			root, err = os.MkdirTemp("", "workingDir")
 			Expect(err).NotTo(HaveOccurred())
 
-			path = file.Name()
-
-			Expect(file.Close()).To(Succeed())
+			path = filepath.Join(root, "app.csproj")
+			Expect(os.WriteFile(path, []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <TargetFramework>net8.0</TargetFramework>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
 		})
 
 		it.After(func() {
-			Expect(os.RemoveAll(path)).To(Succeed())
+			Expect(os.RemoveAll(root)).To(Succeed())
 		})
 
-		context("when project includes target commands that invoke npm", func() {
+		it("returns an empty string for a single target framework", func() {
+			project, err := parser.ParseProject(path, root, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			framework, err := project.PublishFramework("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(framework).To(BeEmpty())
+		})
+
+		context("when the requested framework is the target framework", func() {
+			it("returns it", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				framework, err := project.PublishFramework("net8.0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(framework).To(Equal("net8.0"))
+			})
+		})
+
+		context("when TargetFrameworks is set in Directory.Build.props", func() {
 			it.Before(func() {
-				Expect(os.WriteFile(path, []byte(`
+				Expect(os.WriteFile(path, []byte(`<Project></Project>`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "Directory.Build.props"), []byte(`
 					<Project>
-						<Target Name="first-target">
-							<Exec Command="echo hello" />
-						</Target>
-						<Target Name="second-target">
-							<Exec Command="npm install" />
-						</Target>
+					  <PropertyGroup>
+					    <TargetFrameworks>net8.0;net6.0</TargetFrameworks>
+					  </PropertyGroup>
 					</Project>
 				`), 0600)).To(Succeed())
 			})
 
-			it("returns true", func() {
-				needNode, err := parser.NPMIsRequired(path)
+			it("returns the highest supported framework", func() {
+				project, err := parser.ParseProject(path, root, nil)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(needNode).To(BeTrue())
+				framework, err := project.PublishFramework("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(framework).To(Equal("net8.0"))
+			})
+
+			it("returns the requested framework", func() {
+				project, err := parser.ParseProject(path, root, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				framework, err := project.PublishFramework("NET6.0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(framework).To(Equal("net6.0"))
 			})
 		})
 
-		context("when project does NOT include target commands that invoke node", func() {
+		context("failure cases", func() {
+			context("when the requested framework is not the target framework", func() {
+				it("errors", func() {
+					project, err := parser.ParseProject(path, root, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = project.PublishFramework("net6.0")
+					Expect(err).To(MatchError(`failed to select target framework "net6.0": project targets net8.0`))
+				})
+			})
+		})
+	})
+
+	context("ParseGlobalJSON", func() {
+		var (
+			path string
+			root string
+		)
+
+		it.Before(func() {
+			var err error
+			root, err = os.MkdirTemp("", "workingDir")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(root, "src", "app"), os.ModePerm)).To(Succeed())
+			path = filepath.Join(root, "src", "app", "app.csproj")
+			Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(root)).To(Succeed())
+		})
+
+		it("returns an empty result when there is no global.json", func() {
+			globalJSON, err := parser.ParseGlobalJSON(path, root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(globalJSON).To(Equal(dotnetpublish.GlobalJSON{}))
+		})
+
+		context("when there is a global.json in a parent directory", func() {
 			it.Before(func() {
-				Expect(os.WriteFile(path, []byte(`
-					<Project>
-						<Target Name="first-target">
-							<Exec Command="echo hello" />
-						</Target>
-						<Target Name="second-target">
-							<Exec Command="echo goodbye" />
-						</Target>
-					</Project>
-				`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte(`{
+					"sdk": {
+						"version": "8.0.204",
+						"rollForward": "latestFeature",
+						"allowPrerelease": true
+					}
+				}`), 0600)).To(Succeed())
 			})
 
-			it("returns false", func() {
-				needNode, err := parser.NPMIsRequired(path)
+			it("returns its SDK settings", func() {
+				globalJSON, err := parser.ParseGlobalJSON(path, root)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(globalJSON.Path).To(Equal(filepath.Join(root, "global.json")))
+				Expect(globalJSON.SDK.Version).To(Equal("8.0.204"))
+				Expect(globalJSON.SDK.RollForward).To(Equal("latestFeature"))
+				Expect(globalJSON.SDK.AllowPrerelease).To(BeTrue())
+			})
+
+			context("when there is also a global.json next to the project", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(root, "src", "app", "global.json"), []byte(`{
+						"sdk": {
+							"version": "9.0.100"
+						}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("returns the closest one", func() {
+					globalJSON, err := parser.ParseGlobalJSON(path, root)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(globalJSON.Path).To(Equal(filepath.Join(root, "src", "app", "global.json")))
+					Expect(globalJSON.SDK.Version).To(Equal("9.0.100"))
+				})
+			})
+		})
 
-				Expect(needNode).To(BeFalse())
+		context("when the global.json has comments and trailing commas", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte(`{
+					// Pinned until the build agents are updated
+					"sdk": {
+						"version": "8.0.204", /* the "latest" 8.0 SDK */
+						"rollForward": "latestFeature",
+					},
+				}`), 0600)).To(Succeed())
+			})
+
+			it("returns its SDK settings", func() {
+				globalJSON, err := parser.ParseGlobalJSON(path, root)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(globalJSON.SDK.Version).To(Equal("8.0.204"))
+				Expect(globalJSON.SDK.RollForward).To(Equal("latestFeature"))
+			})
+		})
+
+		context("when a string of the global.json looks like a comment", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte(`{
+					"sdk": {"version": "8.0.204"},
+					"msbuild-sdks": {"Some.Sdk": "https://example.com/*,}"}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("keeps the string", func() {
+				globalJSON, err := parser.ParseGlobalJSON(path, root)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(globalJSON.SDK.Version).To(Equal("8.0.204"))
+			})
+		})
+
+		context("when the global.json is outside of the root directory", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte(`{"sdk": {"version": "8.0.204"}}`), 0600)).To(Succeed())
+			})
+
+			it("ignores it", func() {
+				globalJSON, err := parser.ParseGlobalJSON(path, filepath.Join(root, "src"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(globalJSON).To(Equal(dotnetpublish.GlobalJSON{}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the global.json can not be decoded", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.ParseGlobalJSON(path, root)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse global.json")))
+				})
+			})
+
+			context("when the sdk version is invalid", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte(`{"sdk": {"version": "not-a-version"}}`), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.ParseGlobalJSON(path, root)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse global.json: invalid sdk version "not-a-version"`)))
+				})
+			})
+
+			context("when the rollForward policy is unknown", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(root, "global.json"), []byte(`{"sdk": {"version": "8.0.204", "rollForward": "sideways"}}`), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.ParseGlobalJSON(path, root)
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse global.json: invalid sdk rollForward policy "sideways"`)))
+				})
+			})
+		})
+	})
+	context("ParsePublishProfile", func() {
+		var (
+			path string
+			root string
+		)
+
+		it.Before(func() {
+			var err error
+			root, err = os.MkdirTemp("", "workingDir")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(root, "app", "Properties", "PublishProfiles"), os.ModePerm)).To(Succeed())
+			path = filepath.Join(root, "app", "app.csproj")
+			Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(root, "app", "Properties", "PublishProfiles", "FolderProfile.pubxml"), []byte(`<?xml version="1.0" encoding="utf-8"?>
+<Project>
+  <PropertyGroup>
+    <Configuration>Staging</Configuration>
+    <PublishDir>bin\Staging\publish\</PublishDir>
+    <RuntimeIdentifier>linux-musl-x64</RuntimeIdentifier>
+    <SelfContained Condition="'$(Configuration)' == 'Staging'">true</SelfContained>
+    <AssemblyTitle>$(RuntimeIdentifier) build</AssemblyTitle>
+  </PropertyGroup>
+</Project>`), 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(root)).To(Succeed())
+		})
+
+		it("returns the properties declared by the profile", func() {
+			profile, err := parser.ParsePublishProfile(path, "FolderProfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile).To(Equal(dotnetpublish.PublishProfile{
+				Name: "FolderProfile",
+				Path: filepath.Join(root, "app", "Properties", "PublishProfiles", "FolderProfile.pubxml"),
+				Properties: map[string]string{
+					"configuration":     "Staging",
+					"publishdir":        `bin\Staging\publish\`,
+					"runtimeidentifier": "linux-musl-x64",
+					"selfcontained":     "true",
+					"assemblytitle":     "linux-musl-x64 build",
+				},
+			}))
+		})
+
+		it("accepts the file name of the profile", func() {
+			profile, err := parser.ParsePublishProfile(path, "FolderProfile.pubxml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.Name).To(Equal("FolderProfile"))
+		})
+
+		context("failure cases", func() {
+			context("when the profile does not exist", func() {
+				it("errors", func() {
+					_, err := parser.ParsePublishProfile(path, "Missing")
+					Expect(err).To(MatchError(`failed to find publish profile "Missing": ` + filepath.Join(root, "app", "Properties", "PublishProfiles", "Missing.pubxml") + " does not exist"))
+				})
+			})
+
+			context("when the profile is not valid XML", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(root, "app", "Properties", "PublishProfiles", "FolderProfile.pubxml"), []byte(`<Project>`), 0600)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.ParsePublishProfile(path, "FolderProfile")
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse publish profile "FolderProfile"`)))
+				})
 			})
 		})
 	})
@@ -1,96 +1,377 @@
 package dotnetpublish
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"runtime"
+	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 )
 
 //go:generate faux --interface Executable --output fakes/executable.go
 type Executable interface {
-	Execute(pexec.Execution) error
+	Execute(ctx context.Context, execution pexec.Execution) error
+}
+
+// ErrTimeout is returned by DotnetPublishProcess.Execute when restoring and
+// publishing the project takes longer than the configured timeout.
+var ErrTimeout = errors.New("timed out")
+
+// transientRestoreFailures match the output of NuGet failures that are worth
+// retrying, such as feeds that could not be reached or that answered with a
+// server error.
+var transientRestoreFailures = []*regexp.Regexp{
+	regexp.MustCompile(`\bNU1301\b`),
+	regexp.MustCompile(`Response status code does not indicate success: 5\d\d`),
+	regexp.MustCompile(`\b5\d\d \((Internal Server Error|Bad Gateway|Service Unavailable|Gateway Timeout)\)`),
 }
 
 type DotnetPublishProcess struct {
-	executable Executable
-	logger     scribe.Emitter
-	clock      chronos.Clock
+	executable          Executable
+	logger              scribe.Emitter
+	clock               chronos.Clock
+	restoreAttempts     int
+	restoreRetryBackoff time.Duration
+	timeout             time.Duration
+	verbosity           string
 }
 
 func NewDotnetPublishProcess(executable Executable, logger scribe.Emitter, clock chronos.Clock) DotnetPublishProcess {
 	return DotnetPublishProcess{
-		executable: executable,
-		logger:     logger,
-		clock:      clock,
+		executable:      executable,
+		logger:          logger,
+		clock:           clock,
+		restoreAttempts: 1,
 	}
 }
 
-func (p DotnetPublishProcess) Execute(workingDir, nugetCachePath, projectPath, outputPath string, debug bool, flags []string) error {
-	args := []string{
-		"publish", filepath.Join(workingDir, projectPath), // change to workingDir plus project path
+// WithRestoreRetries returns a process that runs dotnet restore up to the
+// given number of attempts when it fails with a transient NuGet error. The
+// backoff between attempts starts at the given duration and doubles after
+// each attempt.
+func (p DotnetPublishProcess) WithRestoreRetries(attempts int, backoff time.Duration) DotnetPublishProcess {
+	p.restoreAttempts = attempts
+	p.restoreRetryBackoff = backoff
+	return p
+}
+
+// WithTimeout returns a process that stops dotnet when restoring and
+// publishing the project takes longer than the given timeout. A timeout of
+// zero means no timeout.
+func (p DotnetPublishProcess) WithTimeout(timeout time.Duration) DotnetPublishProcess {
+	p.timeout = timeout
+	return p
+}
+
+// WithLogLevel returns a process that restores and publishes the project at
+// the verbosity that msbuildVerbosity maps the given log level to.
+func (p DotnetPublishProcess) WithLogLevel(level string) DotnetPublishProcess {
+	p.verbosity = msbuildVerbosity(level)
+	return p
+}
+
+// Execute restores the packages of the project with dotnet restore and then
+// publishes it with dotnet publish --no-restore, adding the defaults of the
+// buildpack that the flags, properties, project and publish profile leave unset.
+func (p DotnetPublishProcess) Execute(workingDir, nugetCachePath string, options PublishOptions, redactor Redactor) error {
+	flags := append([]string{}, options.Flags...)
+	if options.Project.PublishProfile.Name != "" {
+		flags = append(flags, fmt.Sprintf("-p:PublishProfile=%s", options.Project.PublishProfile.Name))
 	}
+	flags = append(flags, options.Properties.Flags()...)
 
-	if !containsFlag(flags, "--configuration") && !containsFlag(flags, "-c") {
-		if debug {
-			args = append(args, "--configuration", "Debug")
+	publishFlags, err := ParsePublishFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags for dotnet publish: %w", err)
+	}
+	redactor = redactor.WithSecrets(sensitivePropertyValues(publishFlags)...)
+
+	projectFile := filepath.Join(workingDir, options.ProjectPath) // change to workingDir plus project path
+	args := []string{"publish", projectFile}
+	restoreArgs := []string{"restore", projectFile}
+
+	if !publishFlags.Has("--configuration") {
+		configuration, fromProfile := publishConfiguration(options.Project.PublishProfile, options.Debug)
+		if fromProfile {
+			p.logger.Subprocess("Using configuration '%s' from the publish profile '%s'", configuration, options.Project.PublishProfile.Name)
+		}
+		args = append(args, "--configuration", configuration)
+		restoreArgs = append(restoreArgs, fmt.Sprintf("-p:Configuration=%s", configuration))
+	}
+
+	projectRuntimeIdentifier, runtimeIdentifierSource := options.Project.PublishProperty("RuntimeIdentifier")
+	runtimeFlag, hasRuntimeFlag := runtimeIdentifierFlag(publishFlags)
+
+	switch {
+	case hasRuntimeFlag:
+		if options.OverrideRuntimeIdentifier {
+			p.logger.Subprocess("Warning: ignoring BP_DOTNET_RUNTIME_IDENTIFIER ('%s'): BP_DOTNET_PUBLISH_FLAGS sets the runtime identifier ('%s')", options.RuntimeIdentifier, runtimeFlag.Token)
 		} else {
-			args = append(args, "--configuration", "Release")
+			p.logger.Subprocess("Using the runtime identifier from BP_DOTNET_PUBLISH_FLAGS ('%s')", runtimeFlag.Token)
+		}
+	case projectRuntimeIdentifier != "" && !options.OverrideRuntimeIdentifier:
+		p.logger.Subprocess("Using runtime identifier '%s' from %s", projectRuntimeIdentifier, runtimeIdentifierSource)
+	default:
+		if projectRuntimeIdentifier != "" {
+			p.logger.Subprocess("Using runtime identifier '%s' from BP_DOTNET_RUNTIME_IDENTIFIER over '%s' from %s", options.RuntimeIdentifier, projectRuntimeIdentifier, runtimeIdentifierSource)
+		}
+		args = append(args, "--runtime", options.RuntimeIdentifier)
+		restoreArgs = append(restoreArgs, "--runtime", options.RuntimeIdentifier)
+	}
+
+	selfContainedFlag, hasSelfContainedFlag := publishFlags.Option("--self-contained")
+	selfContained, selfContainedSource := options.Project.PublishProperty("SelfContained")
+	publishAot, publishAotSource := options.Project.PublishProperty("PublishAot")
+	publishSingleFile, publishSingleFileSource := options.Project.PublishProperty("PublishSingleFile")
+	switch {
+	case hasSelfContainedFlag:
+		p.logger.Subprocess("Using the self-contained setting from BP_DOTNET_PUBLISH_FLAGS ('%s')", selfContainedFlag.Token)
+	case selfContained != "":
+		p.logger.Subprocess("Using SelfContained '%s' from %s", selfContained, selfContainedSource)
+	// Native AOT applications are always self-contained, and single file
+	// applications default to being self-contained in older SDKs, so these
+	// projects decide for themselves.
+	case strings.EqualFold(publishAot, "true"):
+		p.logger.Subprocess("Using the self-contained setting implied by PublishAot in %s", publishAotSource)
+	case strings.EqualFold(publishSingleFile, "true"):
+		p.logger.Subprocess("Using the self-contained setting implied by PublishSingleFile in %s", publishSingleFileSource)
+	default:
+		args = append(args, "--self-contained", "false")
+		restoreArgs = append(restoreArgs, "-p:SelfContained=false")
+	}
+
+	if !publishFlags.Has("--output") {
+		args = append(args, "--output", options.OutputPath)
+
+		if options.Project.PublishProfile.Property("PublishDir") != "" || options.Project.PublishProfile.Property("PublishUrl") != "" {
+			p.logger.Subprocess("Ignoring the publish directory of the publish profile '%s': the buildpack publishes to its own directory", options.Project.PublishProfile.Name)
 		}
 	}
 
-	arch := runtime.GOARCH
-	if arch == "amd64" {
-		arch = "x64"
+	if options.Framework != "" && !publishFlags.Has("--framework") {
+		args = append(args, "--framework", options.Framework)
 	}
 
-	if !containsFlag(flags, "--runtime") && !containsFlag(flags, "-r") {
-		args = append(args, "--runtime", fmt.Sprintf("linux-%s", arch))
+	if p.verbosity != "" && !publishFlags.Has("--verbosity") {
+		args = append(args, "--verbosity", p.verbosity)
+		restoreArgs = append(restoreArgs, "--verbosity", p.verbosity)
 	}
 
-	if !containsFlag(flags, "--self-contained") && !containsFlag(flags, "--no-self-contained") {
-		args = append(args, "--self-contained", "false")
+	// The output of dotnet is never a terminal, so console colors would only
+	// add escape sequences to the build log.
+	if !publishFlags.Has("-clp") {
+		args = append(args, "-clp:DisableConsoleColor")
+		restoreArgs = append(restoreArgs, "-clp:DisableConsoleColor")
 	}
 
-	if !containsFlag(flags, "--output") && !containsFlag(flags, "-o") {
-		args = append(args, "--output", outputPath)
+	noRestoreFlag, hasNoRestoreFlag := publishFlags.Option("--no-restore")
+	if !hasNoRestoreFlag {
+		args = append(args, "--no-restore")
 	}
 
 	args = append(args, flags...)
+	restoreArgs = append(restoreArgs, publishFlags.RestoreArgs()...)
 
-	p.logger.Subprocess("Running 'dotnet %s'", strings.Join(args, " "))
+	if hasNoRestoreFlag {
+		p.logger.Subprocess("Skipping dotnet restore: restore is disabled by BP_DOTNET_PUBLISH_FLAGS ('%s')", noRestoreFlag.Token)
+	} else {
+		lockFile, err := findLockFile(projectFile, options.Project)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case lockFile != "":
+			p.logger.Subprocess("Restoring in locked mode: found %s", filepath.Base(lockFile))
+			restoreArgs = append(restoreArgs, "--locked-mode")
+		case options.LockedMode:
+			p.logger.Subprocess("Restoring in locked mode: enabled by BP_DOTNET_RESTORE_LOCKED_MODE")
+			restoreArgs = append(restoreArgs, "--locked-mode")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	defer shutdownBuildServers(p.executable, p.logger, workingDir, nugetCachePath)
+
+	if !hasNoRestoreFlag {
+		err = p.restore(ctx, workingDir, nugetCachePath, restoreArgs, redactor)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = p.run(ctx, workingDir, nugetCachePath, args, redactor)
+	return err
+}
+
+// restore runs dotnet restore, and runs it again after a backoff when it fails
+// with a transient NuGet error until it runs out of attempts.
+func (p DotnetPublishProcess) restore(ctx context.Context, workingDir, nugetCachePath string, args []string, redactor Redactor) error {
+	backoff := p.restoreRetryBackoff
+	for attempt := 1; ; attempt++ {
+		output, err := p.run(ctx, workingDir, nugetCachePath, args, redactor)
+		if err == nil || attempt >= p.restoreAttempts || !isTransientRestoreFailure(output) {
+			return err
+		}
+
+		p.logger.Subprocess("Retrying after a transient NuGet error in %s (attempt %d of %d)", backoff, attempt+1, p.restoreAttempts)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return p.contextError(ctx, args)
+		}
+		backoff *= 2
+	}
+}
+
+// run executes dotnet with the given arguments, streaming its output to the
+// log. It returns the output of the command. The secrets of the redactor are
+// masked in both the command line and the output.
+func (p DotnetPublishProcess) run(ctx context.Context, workingDir, nugetCachePath string, args []string, redactor Redactor) (string, error) {
+	p.logger.Subprocess("Running 'dotnet %s'", redactor.Redact(strings.Join(args, " ")))
+
+	output := bytes.NewBuffer(nil)
+	writer := redactor.Writer(io.MultiWriter(p.logger.ActionWriter, output))
 
 	duration, err := p.clock.Measure(func() error {
-		return p.executable.Execute(pexec.Execution{
+		err := p.executable.Execute(ctx, pexec.Execution{
 			Args:   args,
 			Dir:    workingDir,
-			Env:    append(os.Environ(), fmt.Sprintf("NUGET_PACKAGES=%s", nugetCachePath)),
-			Stdout: p.logger.ActionWriter,
-			Stderr: p.logger.ActionWriter,
+			Env:    environment(nugetCachePath),
+			Stdout: writer,
+			Stderr: writer,
 		})
+		return errors.Join(err, writer.Close())
 	})
 
 	if err != nil {
 		p.logger.Action("Failed after %s", duration)
-		return fmt.Errorf("failed to execute 'dotnet publish': %w", err)
+		logMSBuildDiagnostics(p.logger, output.String())
+		if ctx.Err() != nil {
+			return output.String(), p.contextError(ctx, args)
+		}
+		return output.String(), fmt.Errorf("failed to execute 'dotnet %s': %w", args[0], err)
 	}
 
 	p.logger.Action("Completed in %s", duration)
 	p.logger.Break()
 
-	return nil
+	return output.String(), nil
 }
 
-func containsFlag(flags []string, match string) bool {
-	for _, flag := range flags {
-		if strings.HasPrefix(flag, match) {
+func (p DotnetPublishProcess) contextError(ctx context.Context, args []string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("failed to execute 'dotnet %s': %w after %s", args[0], ErrTimeout, p.timeout)
+	}
+
+	return fmt.Errorf("failed to execute 'dotnet %s': %w", args[0], ctx.Err())
+}
+
+// runtimeIdentifierFlag returns the flag that selects the runtime of dotnet
+// publish, either as a runtime identifier or as the architecture or operating
+// system that it is derived from.
+func runtimeIdentifierFlag(flags PublishFlags) (PublishFlag, bool) {
+	for _, name := range []string{"--runtime", "--arch", "--os", "--use-current-runtime"} {
+		if flag, ok := flags.Option(name); ok {
+			return flag, true
+		}
+	}
+
+	return PublishFlag{}, false
+}
+
+func isTransientRestoreFailure(output string) bool {
+	for _, failure := range transientRestoreFailures {
+		if failure.MatchString(output) {
 			return true
 		}
 	}
 	return false
 }
+
+// findLockFile returns the path of the NuGet lock file of the project, either
+// the file set by its NuGetLockFilePath property or packages.lock.json next to
+// the project file. It returns an empty string when there is no lock file.
+func findLockFile(projectFile string, project ProjectModel) (string, error) {
+	projectDir := projectFile
+	info, err := os.Stat(projectFile)
+	if err == nil && !info.IsDir() {
+		projectDir = filepath.Dir(projectFile)
+	}
+
+	lockFile := filepath.Join(projectDir, "packages.lock.json")
+	if path := project.Property("NuGetLockFilePath"); path != "" {
+		lockFile = filepath.Join(projectDir, filepath.FromSlash(strings.ReplaceAll(path, `\`, "/")))
+		if filepath.IsAbs(path) {
+			lockFile = path
+		}
+	}
+
+	exists, err := fs.Exists(lockFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to find lock file: %w", err)
+	}
+
+	if !exists {
+		return "", nil
+	}
+
+	return lockFile, nil
+}
+
+// publishConfiguration returns the configuration that the buildpack publishes
+// with when the flags do not set one, and whether it comes from the publish
+// profile. dotnet publish does not read the configuration from the publish
+// profile, so the buildpack passes it on.
+func publishConfiguration(profile PublishProfile, debug bool) (string, bool) {
+	if debug {
+		return "Debug", false
+	}
+
+	configuration := profile.Property("Configuration")
+	if configuration == "" {
+		configuration = profile.Property("LastUsedBuildConfiguration")
+	}
+
+	if configuration == "" {
+		return "Release", false
+	}
+
+	return configuration, true
+}
+
+// publishGlobalProperties returns the MSBuild global properties that the
+// project is published with, so that it can be evaluated the way dotnet
+// publish evaluates it.
+func publishGlobalProperties(flags PublishFlags, profile PublishProfile, debug bool) map[string]string {
+	properties := flags.GlobalProperties()
+	if !flags.Has("--configuration") {
+		properties["Configuration"], _ = publishConfiguration(profile, debug)
+	}
+
+	if profile.Name != "" {
+		properties["PublishProfile"] = profile.Name
+	}
+
+	return properties
+}
@@ -2,9 +2,11 @@ package dotnetpublish_test
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -26,6 +28,7 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 
 		path       string
 		executable *fakes.Executable
+		executions []pexec.Execution
 		process    dotnetpublish.DotnetPublishProcess
 
 		buffer *bytes.Buffer
@@ -41,7 +44,7 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 		logger := scribe.NewEmitter(buffer)
 
 		now := time.Now()
-		times := []time.Time{now, now.Add(1 * time.Second)}
+		times := []time.Time{now, now.Add(1 * time.Second), now.Add(1 * time.Second), now.Add(2 * time.Second)}
 
 		clock := chronos.NewClock(func() time.Time {
 			if len(times) == 0 {
@@ -53,7 +56,10 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 			return t
 		})
 
-		executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+		executions = nil
+		executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+			executions = append(executions, execution)
+
 			_, err := fmt.Fprintln(execution.Stdout, "stdout-output")
 			Expect(err).ToNot(HaveOccurred())
 			_, err = fmt.Fprintln(execution.Stderr, "stderr-output")
@@ -69,34 +75,70 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 		Expect(os.Setenv("PATH", path)).To(Succeed())
 	})
 
-	it("executes the dotnet publish process", func() {
-		err := process.Execute("some-working-dir", "some/nuget/cache/path", "some/project/path", "some-publish-output-dir", false, []string{"--flag", "value"})
+	it("restores and then executes the dotnet publish process", func() {
+		err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+			ProjectPath:       "some/project/path",
+			OutputPath:        "some-publish-output-dir",
+			RuntimeIdentifier: "linux-x64",
+			Flags:             []string{"--flag", "value"},
+		}, dotnetpublish.Redactor{})
 		Expect(err).NotTo(HaveOccurred())
 
+		Expect(executions).To(HaveLen(3))
+
+		restoreArgs := []string{
+			"restore", "some-working-dir/some/project/path",
+			"-p:Configuration=Release",
+			"--runtime", "linux-x64",
+			"-p:SelfContained=false",
+			"-clp:DisableConsoleColor",
+		}
+
+		Expect(executions[0].Args).To(Equal(restoreArgs))
+		Expect(executions[0].Dir).To(Equal("some-working-dir"))
+		Expect(executions[0].Env).To(ContainElement("NUGET_PACKAGES=some/nuget/cache/path"))
+
 		args := []string{
 			"publish", "some-working-dir/some/project/path",
 			"--configuration", "Release",
 			"--runtime", "linux-x64",
 			"--self-contained", "false",
 			"--output", "some-publish-output-dir",
+			"-clp:DisableConsoleColor",
+			"--no-restore",
 			"--flag", "value",
 		}
 
-		Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal(args))
+		Expect(executions[1].Args).To(Equal(args))
+
+		Expect(executions[1].Dir).To(Equal("some-working-dir"))
+		Expect(executions[1].Env).To(ContainElement("NUGET_PACKAGES=some/nuget/cache/path"))
 
-		Expect(executable.ExecuteCall.Receives.Execution.Dir).To(Equal("some-working-dir"))
-		Expect(executable.ExecuteCall.Receives.Execution.Env).To(ContainElement("NUGET_PACKAGES=some/nuget/cache/path"))
+		Expect(executions[2].Args).To(Equal([]string{"build-server", "shutdown"}))
+		Expect(executions[2].Dir).To(Equal("some-working-dir"))
 
 		Expect(buffer.String()).To(ContainLines(
+			fmt.Sprintf("    Running 'dotnet %s'", strings.Join(restoreArgs, " ")),
+			"      stdout-output",
+			"      stderr-output",
+			"      Completed in 1s",
+			"",
 			fmt.Sprintf("    Running 'dotnet %s'", strings.Join(args, " ")),
 			"      stdout-output",
 			"      stderr-output",
 			"      Completed in 1s",
 		))
 	})
+
 	context("when debug mode is enabled", func() {
 		it("adds Debug to the publish configuration", func() {
-			err := process.Execute("some-working-dir", "some/nuget/cache/path", "some/project/path", "some-publish-output-dir", true, []string{"--flag", "value"})
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Debug:             true,
+				Flags:             []string{"--flag", "value"},
+			}, dotnetpublish.Redactor{})
 			Expect(err).NotTo(HaveOccurred())
 
 			args := []string{
@@ -105,13 +147,15 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 				"--runtime", "linux-x64",
 				"--self-contained", "false",
 				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
 				"--flag", "value",
 			}
 
-			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal(args))
+			Expect(executions[1].Args).To(Equal(args))
 
-			Expect(executable.ExecuteCall.Receives.Execution.Dir).To(Equal("some-working-dir"))
-			Expect(executable.ExecuteCall.Receives.Execution.Env).To(ContainElement("NUGET_PACKAGES=some/nuget/cache/path"))
+			Expect(executions[1].Dir).To(Equal("some-working-dir"))
+			Expect(executions[1].Env).To(ContainElement("NUGET_PACKAGES=some/nuget/cache/path"))
 
 			Expect(buffer.String()).To(ContainLines(
 				fmt.Sprintf("    Running 'dotnet %s'", strings.Join(args, " ")),
@@ -122,32 +166,337 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when a target framework is given", func() {
+		it("adds it to the publish arguments", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				Framework:         "net8.0",
+				RuntimeIdentifier: "linux-x64",
+				Flags:             []string{"--flag", "value"},
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Release",
+				"--runtime", "linux-x64",
+				"--self-contained", "false",
+				"--output", "some-publish-output-dir",
+				"--framework", "net8.0",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+				"--flag", "value",
+			}))
+		})
+
+		context("when the user passes a framework flag", func() {
+			it("does not override it", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					Framework:         "net8.0",
+					RuntimeIdentifier: "linux-x64",
+					Flags:             []string{"--framework", "net6.0"},
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[1].Args).To(Equal([]string{
+					"publish", "some-working-dir/some/project/path",
+					"--configuration", "Release",
+					"--runtime", "linux-x64",
+					"--self-contained", "false",
+					"--output", "some-publish-output-dir",
+					"-clp:DisableConsoleColor",
+					"--no-restore",
+					"--framework", "net6.0",
+				}))
+			})
+		})
+	})
+
 	context("when the user passes flags that the buildpack sets by default", func() {
 		it("overrides the default value with the user-provided one", func() {
-			err := process.Execute("some-working-dir", "some/nuget/cache/path", "some/project/path", "some-publish-output-dir",
-				true,
-				[]string{"--runtime", "user-value",
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Debug:             true,
+				Flags: []string{
+					"--runtime", "user-value",
 					"--self-contained=true",
 					"--configuration", "UserConfiguration",
 					"--output", "some-user-output-dir",
-				})
+				},
+				Project: dotnetpublish.ProjectModel{
+					Properties: map[string]string{"runtimeidentifier": "linux-musl-x64", "selfcontained": "false"},
+				},
+			}, dotnetpublish.Redactor{})
 			Expect(err).NotTo(HaveOccurred())
 
 			args := []string{
 				"publish", "some-working-dir/some/project/path",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
 				"--runtime", "user-value",
 				"--self-contained=true",
 				"--configuration", "UserConfiguration",
 				"--output", "some-user-output-dir",
 			}
 
-			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal(args))
+			Expect(executions[1].Args).To(Equal(args))
+
+			Expect(buffer.String()).To(ContainLines(
+				"    Using the runtime identifier from BP_DOTNET_PUBLISH_FLAGS ('--runtime user-value')",
+				"    Using the self-contained setting from BP_DOTNET_PUBLISH_FLAGS ('--self-contained=true')",
+			))
+		})
+	})
+
+	context("when a runtime identifier is given", func() {
+		it("uses it as the default runtime", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-musl-arm64",
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Release",
+				"--runtime", "linux-musl-arm64",
+				"--self-contained", "false",
+				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+			}))
+		})
+	})
+
+	context("when the project sets RuntimeIdentifier and SelfContained", func() {
+		it("does not override them", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Project: dotnetpublish.ProjectModel{
+					Properties: map[string]string{"runtimeidentifier": "linux-musl-x64", "selfcontained": "true"},
+				},
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Release",
+				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+			}))
+
+			Expect(buffer.String()).To(ContainLines(
+				"    Using runtime identifier 'linux-musl-x64' from the project file",
+				"    Using SelfContained 'true' from the project file",
+			))
+		})
+
+		context("when the runtime identifier is an override", func() {
+			it("uses it over the runtime identifier of the project", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:               "some/project/path",
+					OutputPath:                "some-publish-output-dir",
+					RuntimeIdentifier:         "linux-arm64",
+					OverrideRuntimeIdentifier: true,
+					Project: dotnetpublish.ProjectModel{
+						Properties: map[string]string{"runtimeidentifier": "linux-musl-x64", "selfcontained": "true"},
+					},
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[1].Args).To(Equal([]string{
+					"publish", "some-working-dir/some/project/path",
+					"--configuration", "Release",
+					"--runtime", "linux-arm64",
+					"--output", "some-publish-output-dir",
+					"-clp:DisableConsoleColor",
+					"--no-restore",
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Using runtime identifier 'linux-arm64' from BP_DOTNET_RUNTIME_IDENTIFIER over 'linux-musl-x64' from the project file"))
+			})
+
+			context("when the flags set the runtime identifier as well", func() {
+				it("warns that the override is ignored", func() {
+					err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+						ProjectPath:               "some/project/path",
+						OutputPath:                "some-publish-output-dir",
+						RuntimeIdentifier:         "linux-arm64",
+						OverrideRuntimeIdentifier: true,
+						Flags:                     []string{"-r", "linux-x64"},
+					}, dotnetpublish.Redactor{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(executions[1].Args).NotTo(ContainElement("linux-arm64"))
+					Expect(buffer.String()).To(ContainSubstring("Warning: ignoring BP_DOTNET_RUNTIME_IDENTIFIER ('linux-arm64'): BP_DOTNET_PUBLISH_FLAGS sets the runtime identifier ('-r linux-x64')"))
+				})
+			})
+		})
+
+		context("when they are set for the Release|AnyCPU configuration and platform", func() {
+			var workingDir string
+
+			it.Before(func() {
+				var err error
+				workingDir, err = os.MkdirTemp("", "working-dir")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), []byte(`
+					<Project Sdk="Microsoft.NET.Sdk">
+					  <PropertyGroup>
+					    <TargetFramework>net8.0</TargetFramework>
+					  </PropertyGroup>
+					  <PropertyGroup Condition="'$(Configuration)|$(Platform)'=='Release|AnyCPU'">
+					    <RuntimeIdentifier>linux-musl-x64</RuntimeIdentifier>
+					    <SelfContained>true</SelfContained>
+					  </PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(workingDir)).To(Succeed())
+			})
+
+			it("does not override them", func() {
+				project, err := dotnetpublish.NewProjectFileParser().ParseProject(filepath.Join(workingDir, "app.csproj"), workingDir, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = process.Execute(workingDir, "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "app.csproj",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Project:           project,
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[1].Args).To(Equal([]string{
+					"publish", filepath.Join(workingDir, "app.csproj"),
+					"--configuration", "Release",
+					"--output", "some-publish-output-dir",
+					"-clp:DisableConsoleColor",
+					"--no-restore",
+				}))
+
+				Expect(buffer.String()).To(ContainLines(
+					"    Using runtime identifier 'linux-musl-x64' from the project file",
+					"    Using SelfContained 'true' from the project file",
+				))
+			})
+		})
+	})
+
+	context("when the project is published with a publish profile", func() {
+		var project dotnetpublish.ProjectModel
+
+		it.Before(func() {
+			project = dotnetpublish.ProjectModel{
+				Properties: map[string]string{"runtimeidentifier": "linux-x64"},
+				PublishProfile: dotnetpublish.PublishProfile{
+					Name: "FolderProfile",
+					Properties: map[string]string{
+						"configuration":     "Staging",
+						"publishdir":        "bin/Staging/publish/",
+						"runtimeidentifier": "linux-musl-x64",
+						"selfcontained":     "true",
+					},
+				},
+			}
+		})
+
+		it("passes the profile on and uses its settings over those of the project", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Project:           project,
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Staging",
+				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+				"-p:PublishProfile=FolderProfile",
+			}))
+			Expect(executions[0].Args).To(ContainElement("-p:Configuration=Staging"))
+
+			Expect(buffer.String()).To(ContainLines(
+				"    Using configuration 'Staging' from the publish profile 'FolderProfile'",
+				"    Using runtime identifier 'linux-musl-x64' from the publish profile 'FolderProfile'",
+				"    Using SelfContained 'true' from the publish profile 'FolderProfile'",
+				"    Ignoring the publish directory of the publish profile 'FolderProfile': the buildpack publishes to its own directory",
+			))
+		})
+
+		context("when the build is a debug build", func() {
+			it("uses the Debug configuration", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Debug:             true,
+					Project:           project,
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[1].Args).To(ContainElements("--configuration", "Debug"))
+				Expect(buffer.String()).NotTo(ContainSubstring("Using configuration 'Staging'"))
+			})
+		})
+	})
+
+	context("when the project publishes a native AOT or single file application", func() {
+		it("lets the project decide whether it is self-contained", func() {
+			for property, message := range map[string]string{
+				"PublishAot":        "    Using the self-contained setting implied by PublishAot in the project file",
+				"PublishSingleFile": "    Using the self-contained setting implied by PublishSingleFile in the project file",
+			} {
+				buffer.Reset()
+
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Project: dotnetpublish.ProjectModel{
+						Properties: map[string]string{property: "True"},
+					},
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[1].Args).To(Equal([]string{
+					"publish", "some-working-dir/some/project/path",
+					"--configuration", "Release",
+					"--runtime", "linux-x64",
+					"--output", "some-publish-output-dir",
+					"-clp:DisableConsoleColor",
+					"--no-restore",
+				}))
+
+				Expect(buffer.String()).To(ContainLines(message))
+			}
 		})
 	})
 
 	context("when the user passes --no-self-contained, equivalent to --self-contained=false", func() {
 		it("overrides the buildpack's value for self-contained with the user-provided one", func() {
-			err := process.Execute("some-working-dir", "some/nuget/cache/path", "some/project/path", "some-publish-output-dir", false, []string{"--no-self-contained"})
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Flags:             []string{"--no-self-contained"},
+			}, dotnetpublish.Redactor{})
 			Expect(err).NotTo(HaveOccurred())
 
 			args := []string{
@@ -155,17 +504,489 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 				"--configuration", "Release",
 				"--runtime", "linux-x64",
 				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
 				"--no-self-contained",
 			}
 
-			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal(args))
+			Expect(executions[1].Args).To(Equal(args))
+		})
+	})
+
+	context("when the user passes MSBuild properties that the buildpack sets by default", func() {
+		it("does not add the defaults", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				Framework:         "net8.0",
+				RuntimeIdentifier: "linux-x64",
+				Flags: []string{
+					"/p:Configuration=Debug",
+					"-p:RuntimeIdentifier=linux-musl-x64;SelfContained=true",
+					"--output=some-user-output-dir",
+					"-clp:NoSummary",
+				},
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--framework", "net8.0",
+				"--no-restore",
+				"/p:Configuration=Debug",
+				"-p:RuntimeIdentifier=linux-musl-x64;SelfContained=true",
+				"--output=some-user-output-dir",
+				"-clp:NoSummary",
+			}))
+		})
+	})
+
+	context("when the user passes flags that also apply to dotnet restore", func() {
+		it("passes them to dotnet restore", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Flags: []string{
+					"-c", "Debug",
+					"--no-self-contained",
+					"-r", "linux-arm64",
+					"--source", "https://example.com/nuget",
+					"-p:Version=1.2.3",
+					"--output", "some-user-output-dir",
+				},
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(Equal([]string{
+				"restore", "some-working-dir/some/project/path",
+				"-clp:DisableConsoleColor",
+				"-p:Configuration=Debug",
+				"-p:SelfContained=false",
+				"-r", "linux-arm64",
+				"--source", "https://example.com/nuget",
+				"-p:Version=1.2.3",
+			}))
+		})
+	})
+
+	context("when MSBuild properties are given", func() {
+		it("passes them after the flags and leaves out the defaults they set", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Flags:             []string{"--verbosity", "normal"},
+				Properties: dotnetpublish.MSBuildProperties{
+					"SelfContained":   "true",
+					"DefineConstants": "A;B",
+				},
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Release",
+				"--runtime", "linux-x64",
+				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+				"--verbosity", "normal",
+				`-p:DefineConstants="A;B"`,
+				"-p:SelfContained=true",
+			}))
+			Expect(executions[0].Args).To(ContainElement("-p:SelfContained=true"))
+		})
+
+		context("when they contradict the flags", func() {
+			it("returns an error", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Flags:             []string{"-c", "Debug"},
+					Properties: dotnetpublish.MSBuildProperties{
+						"Configuration": "Release",
+					},
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError(`failed to parse flags for dotnet publish: flags "-c Debug" and "-p:Configuration=Release" contradict each other`))
+			})
+		})
+	})
+
+	context("when the flags set sensitive MSBuild properties", func() {
+		it.Before(func() {
+			executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+				executions = append(executions, execution)
+
+				_, err := fmt.Fprintln(execution.Stdout, "Using feed key some-api-key")
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			}
+		})
+
+		it("redacts their values from the command line and the output", func() {
+			redactor, err := dotnetpublish.NewRedactor("")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				Flags: []string{
+					"-p:NuGetPassword=some-password",
+					"-p:FeedApiKey=some-api-key",
+					"-p:Version=1.2.3",
+				},
+			}, redactor)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[1].Args).To(ContainElement("-p:NuGetPassword=some-password"))
+
+			Expect(buffer.String()).To(ContainSubstring("-p:NuGetPassword=*** -p:FeedApiKey=*** -p:Version=1.2.3"))
+			Expect(buffer.String()).To(ContainSubstring("Using feed key ***"))
+			Expect(buffer.String()).NotTo(ContainSubstring("some-password"))
+			Expect(buffer.String()).NotTo(ContainSubstring("some-api-key"))
+		})
+	})
+
+	context("when a log level is given", func() {
+		it("runs dotnet restore and dotnet publish at the matching verbosity", func() {
+			err := process.WithLogLevel("DEBUG").Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(ContainElements("--verbosity", "normal"))
+			Expect(executions[1].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Release",
+				"--runtime", "linux-x64",
+				"--self-contained", "false",
+				"--output", "some-publish-output-dir",
+				"--verbosity", "normal",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+			}))
+		})
+
+		context("when the user passes a verbosity flag", func() {
+			it("does not override it", func() {
+				err := process.WithLogLevel("INFO").Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Flags:             []string{"-v:q"},
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[0].Args).NotTo(ContainElement("--verbosity"))
+				Expect(executions[0].Args).To(ContainElement("-v:q"))
+				Expect(executions[1].Args).NotTo(ContainElement("--verbosity"))
+				Expect(executions[1].Args).To(ContainElement("-v:q"))
+			})
+		})
+	})
+
+	context("when setting up the environment of dotnet", func() {
+		var telemetry string
+
+		it.Before(func() {
+			telemetry = os.Getenv("DOTNET_CLI_TELEMETRY_OPTOUT")
+			Expect(os.Unsetenv("DOTNET_NOLOGO")).To(Succeed())
+			Expect(os.Unsetenv("MSBUILDTERMINALLOGGER")).To(Succeed())
+			Expect(os.Setenv("DOTNET_CLI_TELEMETRY_OPTOUT", "false")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Setenv("DOTNET_CLI_TELEMETRY_OPTOUT", telemetry)).To(Succeed())
+		})
+
+		it("adds the defaults that the user does not override", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, execution := range executions {
+				Expect(execution.Env).To(ContainElements(
+					"DOTNET_NOLOGO=true",
+					"MSBUILDTERMINALLOGGER=off",
+					"DOTNET_CLI_TELEMETRY_OPTOUT=false",
+				))
+				Expect(execution.Env).NotTo(ContainElement("DOTNET_CLI_TELEMETRY_OPTOUT=true"))
+			}
+		})
+	})
+
+	context("when the user passes --no-restore", func() {
+		it("does not run dotnet restore", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				LockedMode:        true,
+				Flags:             []string{"--no-restore"},
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(Equal([]string{
+				"publish", "some-working-dir/some/project/path",
+				"--configuration", "Release",
+				"--runtime", "linux-x64",
+				"--self-contained", "false",
+				"--output", "some-publish-output-dir",
+				"-clp:DisableConsoleColor",
+				"--no-restore",
+			}))
+			Expect(executions[1].Args).To(Equal([]string{"build-server", "shutdown"}))
+
+			Expect(buffer.String()).To(ContainSubstring("Skipping dotnet restore: restore is disabled by BP_DOTNET_PUBLISH_FLAGS ('--no-restore')"))
+			Expect(buffer.String()).NotTo(ContainSubstring("locked mode"))
+		})
+	})
+
+	context("when the project has a lock file", func() {
+		var workingDir string
+
+		it.Before(func() {
+			var err error
+			workingDir, err = os.MkdirTemp("", "working-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "packages.lock.json"), nil, 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workingDir)).To(Succeed())
+		})
+
+		it("restores in locked mode", func() {
+			err := process.Execute(workingDir, "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "app.csproj",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(ContainElement("--locked-mode"))
+			Expect(executions[1].Args).NotTo(ContainElement("--locked-mode"))
+
+			Expect(buffer.String()).To(ContainLines("    Restoring in locked mode: found packages.lock.json"))
+		})
+
+		context("when the project sets NuGetLockFilePath", func() {
+			it.Before(func() {
+				Expect(os.Rename(filepath.Join(workingDir, "packages.lock.json"), filepath.Join(workingDir, "app.lock.json"))).To(Succeed())
+			})
+
+			it("restores in locked mode", func() {
+				err := process.Execute(workingDir, "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "app.csproj",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Project: dotnetpublish.ProjectModel{
+						Properties: map[string]string{"nugetlockfilepath": "app.lock.json"},
+					},
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[0].Args).To(ContainElement("--locked-mode"))
+				Expect(buffer.String()).To(ContainLines("    Restoring in locked mode: found app.lock.json"))
+			})
+		})
+	})
+
+	context("when locked mode is enabled", func() {
+		it("restores in locked mode", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+				LockedMode:        true,
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(ContainElement("--locked-mode"))
+			Expect(buffer.String()).To(ContainLines("    Restoring in locked mode: enabled by BP_DOTNET_RESTORE_LOCKED_MODE"))
+		})
+	})
+
+	context("when dotnet restore fails with a transient NuGet error", func() {
+		it.Before(func() {
+			executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+				executions = append(executions, execution)
+
+				if execution.Args[0] == "restore" && len(executions) < 3 {
+					_, err := fmt.Fprintln(execution.Stdout, "error NU1301: Unable to load the service index for source https://example.com/v3/index.json.")
+					Expect(err).ToNot(HaveOccurred())
+					return errors.New("exit status 1")
+				}
+
+				return nil
+			}
+
+			process = process.WithRestoreRetries(3, time.Millisecond)
+		})
+
+		it("retries it with a backoff", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+				ProjectPath:       "some/project/path",
+				OutputPath:        "some-publish-output-dir",
+				RuntimeIdentifier: "linux-x64",
+			}, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions).To(HaveLen(5))
+			Expect(executions[0].Args[0]).To(Equal("restore"))
+			Expect(executions[1].Args[0]).To(Equal("restore"))
+			Expect(executions[2].Args[0]).To(Equal("restore"))
+			Expect(executions[3].Args[0]).To(Equal("publish"))
+
+			Expect(buffer.String()).To(ContainLines(
+				"      error NU1301: Unable to load the service index for source https://example.com/v3/index.json.",
+				"      Failed after 1s",
+				"",
+				"    Found 1 error(s):",
+				"      error NU1301: Unable to load the service index for source https://example.com/v3/index.json.",
+				"        Hint: A package source could not be reached. Check the sources and credentials of the NuGet.Config, for example of the nugetconfig service binding.",
+				"",
+				"    Retrying after a transient NuGet error in 1ms (attempt 2 of 3)",
+			))
+			Expect(buffer.String()).To(ContainLines(
+				"    Retrying after a transient NuGet error in 2ms (attempt 3 of 3)",
+			))
+		})
+
+		context("when it runs out of attempts", func() {
+			it.Before(func() {
+				process = process.WithRestoreRetries(2, time.Millisecond)
+			})
+
+			it("returns an error", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError("failed to execute 'dotnet restore': exit status 1"))
+				Expect(executions).To(HaveLen(3))
+			})
+		})
+
+		context("when the server answers with an error", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+					executions = append(executions, execution)
+
+					if execution.Args[0] == "restore" && len(executions) == 1 {
+						_, err := fmt.Fprintln(execution.Stdout, "Response status code does not indicate success: 503 (Service Unavailable).")
+						Expect(err).ToNot(HaveOccurred())
+						return errors.New("exit status 1")
+					}
+
+					return nil
+				}
+			})
+
+			it("retries it", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					ProjectPath:       "some/project/path",
+					OutputPath:        "some-publish-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(executions).To(HaveLen(4))
+			})
 		})
 	})
 
 	context("failure cases", func() {
-		context("when the dotnet publish executable errors", func() {
+		context("when the flags contradict each other", func() {
+			it("returns an error without retrying or running dotnet publish", func() {
+				process = process.WithRestoreRetries(3, time.Millisecond)
+
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+					Flags:             []string{"-r", "linux-x64", "-p:RuntimeIdentifier=linux-arm64"},
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError(`failed to parse flags for dotnet publish: flags "-r linux-x64" and "-p:RuntimeIdentifier=linux-arm64" contradict each other`))
+				Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when dotnet publish reports MSBuild errors", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+					if execution.Args[0] == "publish" {
+						_, err := fmt.Fprintln(execution.Stdout, "/workspace/app.csproj : error NU1101: Unable to find package Some.Package. [/workspace/app.csproj]")
+						Expect(err).ToNot(HaveOccurred())
+						_, err = fmt.Fprintln(execution.Stdout, "/workspace/Program.cs(12,5): error CS1002: ; expected [/workspace/app.csproj]")
+						Expect(err).ToNot(HaveOccurred())
+						return errors.New("exit status 1")
+					}
+					return nil
+				}
+			})
+
+			it("logs a summary of the errors with hints", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError("failed to execute 'dotnet publish': exit status 1"))
+
+				Expect(buffer.String()).To(ContainLines(
+					"      Failed after 1s",
+					"",
+					"    Found 2 error(s):",
+					"      /workspace/app.csproj: error NU1101: Unable to find package Some.Package.",
+					"        Hint: A package could not be found. Check that its source is configured, for example in a NuGet.Config provided with a nugetconfig service binding.",
+					"      /workspace/Program.cs(12,5): error CS1002: ; expected",
+				))
+			})
+		})
+
+		context("when restoring and publishing takes longer than the timeout", func() {
 			it.Before(func() {
-				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+					executions = append(executions, execution)
+
+					if execution.Args[0] == "publish" {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+					return nil
+				}
+
+				process = process.WithTimeout(10 * time.Millisecond)
+			})
+
+			it("returns a timeout error and shuts down the build servers", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError("failed to execute 'dotnet publish': timed out after 10ms"))
+				Expect(errors.Is(err, dotnetpublish.ErrTimeout)).To(BeTrue())
+
+				Expect(executions).To(HaveLen(3))
+				Expect(executions[2].Args).To(Equal([]string{"build-server", "shutdown"}))
+			})
+		})
+
+		context("when dotnet restore errors", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+					executions = append(executions, execution)
+
 					_, err := fmt.Fprintln(execution.Stdout, "stdout-output")
 					Expect(err).ToNot(HaveOccurred())
 					_, err = fmt.Fprintln(execution.Stderr, "stderr-output")
@@ -175,16 +996,70 @@ func testDotnetPublishProcess(t *testing.T, context spec.G, it spec.S) {
 				}
 			})
 
+			it("returns an error without retrying or running dotnet publish", func() {
+				process = process.WithRestoreRetries(3, time.Millisecond)
+
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError("failed to execute 'dotnet restore': execution error"))
+				Expect(executions).To(HaveLen(2))
+				Expect(executions[1].Args).To(Equal([]string{"build-server", "shutdown"}))
+			})
+
+			it("logs the command output", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
+				Expect(err).To(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainLines(
+					"      stdout-output",
+					"      stderr-output",
+					"      Failed after 1s",
+				))
+			})
+		})
+
+		context("when the dotnet publish executable errors", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+					executions = append(executions, execution)
+
+					_, err := fmt.Fprintln(execution.Stdout, "stdout-output")
+					Expect(err).ToNot(HaveOccurred())
+					_, err = fmt.Fprintln(execution.Stderr, "stderr-output")
+					Expect(err).ToNot(HaveOccurred())
+
+					if execution.Args[0] == "publish" {
+						return errors.New("execution error")
+					}
+					return nil
+				}
+			})
+
 			it("returns an error", func() {
-				err := process.Execute("some-working-dir", "some/nuget/cache/path", "", "some-output-dir", false, []string{})
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
 				Expect(err).To(MatchError("failed to execute 'dotnet publish': execution error"))
+				Expect(executions).To(HaveLen(3))
 			})
 
 			it("logs the command output", func() {
-				err := process.Execute("some-working-dir", "some/nuget/cache/path", "", "some-output-dir", false, []string{})
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", dotnetpublish.PublishOptions{
+					OutputPath:        "some-output-dir",
+					RuntimeIdentifier: "linux-x64",
+				}, dotnetpublish.Redactor{})
 				Expect(err).To(HaveOccurred())
 
 				Expect(buffer.String()).To(ContainLines(
+					"      Completed in 1s",
+					"",
+					"    Running 'dotnet publish some-working-dir --configuration Release --runtime linux-x64 --self-contained false --output some-output-dir -clp:DisableConsoleColor --no-restore'",
 					"      stdout-output",
 					"      stderr-output",
 					"      Failed after 1s",
@@ -0,0 +1,113 @@
+package dotnetpublish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// DotnetTestProcess runs the test projects of the app with dotnet test before
+// the app is published.
+type DotnetTestProcess struct {
+	executable Executable
+	logger     scribe.Emitter
+	clock      chronos.Clock
+	verbosity  string
+}
+
+func NewDotnetTestProcess(executable Executable, logger scribe.Emitter, clock chronos.Clock) DotnetTestProcess {
+	return DotnetTestProcess{
+		executable: executable,
+		logger:     logger,
+		clock:      clock,
+	}
+}
+
+// WithLogLevel returns a process that runs dotnet test at the verbosity that
+// msbuildVerbosity maps the given log level to.
+func (p DotnetTestProcess) WithLogLevel(level string) DotnetTestProcess {
+	p.verbosity = msbuildVerbosity(level)
+	return p
+}
+
+// Execute runs dotnet test for each of the given projects, whose paths are
+// relative to the working directory, and stops at the first project whose
+// tests fail. The results of each project are written to a TRX file named
+// after the project in the results directory. Packages are restored into the
+// NuGet cache that dotnet publish uses. The secrets of the redactor are masked
+// in the log.
+func (p DotnetTestProcess) Execute(workingDir, nugetCachePath string, projectPaths []string, resultsPath string, debug bool, redactor Redactor) error {
+	configuration := "Release"
+	if debug {
+		configuration = "Debug"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	for _, projectPath := range projectPaths {
+		name := strings.TrimSuffix(filepath.Base(projectPath), filepath.Ext(projectPath))
+		args := []string{
+			"test", filepath.Join(workingDir, projectPath),
+			"--configuration", configuration,
+			"--logger", fmt.Sprintf("trx;LogFileName=%s.trx", name),
+			"--results-directory", resultsPath,
+		}
+
+		if p.verbosity != "" {
+			args = append(args, "--verbosity", p.verbosity)
+		}
+		args = append(args, "-clp:DisableConsoleColor")
+
+		err := p.run(ctx, workingDir, nugetCachePath, args, redactor)
+		if err != nil {
+			// dotnet publish does not run after failing tests, so it cannot
+			// shut down the build servers.
+			shutdownBuildServers(p.executable, p.logger, workingDir, nugetCachePath)
+			return fmt.Errorf("failed to test %s: %w", projectPath, err)
+		}
+	}
+
+	return nil
+}
+
+func (p DotnetTestProcess) run(ctx context.Context, workingDir, nugetCachePath string, args []string, redactor Redactor) error {
+	p.logger.Subprocess("Running 'dotnet %s'", redactor.Redact(strings.Join(args, " ")))
+
+	output := bytes.NewBuffer(nil)
+	writer := redactor.Writer(io.MultiWriter(p.logger.ActionWriter, output))
+
+	duration, err := p.clock.Measure(func() error {
+		err := p.executable.Execute(ctx, pexec.Execution{
+			Args:   args,
+			Dir:    workingDir,
+			Env:    environment(nugetCachePath),
+			Stdout: writer,
+			Stderr: writer,
+		})
+		return errors.Join(err, writer.Close())
+	})
+	if err != nil {
+		p.logger.Action("Failed after %s", duration)
+		logMSBuildDiagnostics(p.logger, output.String())
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("failed to execute 'dotnet %s': %w", args[0], err)
+	}
+
+	p.logger.Action("Completed in %s", duration)
+	p.logger.Break()
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package dotnetpublish_test
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/paketo-buildpacks/dotnet-publish/fakes"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	. "github.com/paketo-buildpacks/occam/matchers"
+)
+
+func testDotnetTestProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+		executions []pexec.Execution
+		process    dotnetpublish.DotnetTestProcess
+
+		buffer *bytes.Buffer
+	)
+
+	it.Before(func() {
+		executable = &fakes.Executable{}
+
+		buffer = bytes.NewBuffer(nil)
+		logger := scribe.NewEmitter(buffer)
+
+		now := time.Now()
+		times := []time.Time{now, now.Add(1 * time.Second), now.Add(1 * time.Second), now.Add(2 * time.Second)}
+
+		clock := chronos.NewClock(func() time.Time {
+			if len(times) == 0 {
+				return time.Now()
+			}
+
+			t := times[0]
+			times = times[1:]
+			return t
+		})
+
+		executions = nil
+		executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+			executions = append(executions, execution)
+
+			_, err := fmt.Fprintln(execution.Stdout, "Passed!  - Failed: 0, Passed: 3")
+			Expect(err).ToNot(HaveOccurred())
+
+			return nil
+		}
+
+		process = dotnetpublish.NewDotnetTestProcess(executable, logger, clock)
+	})
+
+	it("runs dotnet test for each project and writes TRX results", func() {
+		err := process.Execute("some-working-dir", "some/nuget/cache/path", []string{"tests/Unit/Unit.csproj", "tests/Integration/Integration.fsproj"}, "some-working-dir/TestResults", false, dotnetpublish.Redactor{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(executions).To(HaveLen(2))
+
+		args := []string{
+			"test", "some-working-dir/tests/Unit/Unit.csproj",
+			"--configuration", "Release",
+			"--logger", "trx;LogFileName=Unit.trx",
+			"--results-directory", "some-working-dir/TestResults",
+			"-clp:DisableConsoleColor",
+		}
+		Expect(executions[0].Args).To(Equal(args))
+		Expect(executions[0].Dir).To(Equal("some-working-dir"))
+		Expect(executions[0].Env).To(ContainElement("NUGET_PACKAGES=some/nuget/cache/path"))
+
+		Expect(executions[1].Args).To(ContainElements("some-working-dir/tests/Integration/Integration.fsproj", "trx;LogFileName=Integration.trx"))
+
+		Expect(buffer.String()).To(ContainLines(
+			fmt.Sprintf("    Running 'dotnet %s'", strings.Join(args, " ")),
+			"      Passed!  - Failed: 0, Passed: 3",
+			"      Completed in 1s",
+		))
+	})
+
+	context("when debug mode is enabled", func() {
+		it("tests the Debug configuration", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", []string{"tests/Unit/Unit.csproj"}, "some-working-dir/TestResults", true, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(ContainElements("--configuration", "Debug"))
+		})
+	})
+
+	context("when a log level is given", func() {
+		it.Before(func() {
+			process = process.WithLogLevel("DEBUG")
+		})
+
+		it("sets the verbosity of dotnet test", func() {
+			err := process.Execute("some-working-dir", "some/nuget/cache/path", []string{"tests/Unit/Unit.csproj"}, "some-working-dir/TestResults", false, dotnetpublish.Redactor{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions[0].Args).To(ContainElements("--verbosity", "normal"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the tests of a project fail", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(ctx gocontext.Context, execution pexec.Execution) error {
+					executions = append(executions, execution)
+
+					if execution.Args[0] == "test" {
+						_, err := fmt.Fprintln(execution.Stdout, "Failed!  - Failed: 1, Passed: 2")
+						Expect(err).ToNot(HaveOccurred())
+						return errors.New("exit status 1")
+					}
+					return nil
+				}
+			})
+
+			it("returns an error without testing the other projects", func() {
+				err := process.Execute("some-working-dir", "some/nuget/cache/path", []string{"tests/Unit/Unit.csproj", "tests/Integration/Integration.csproj"}, "some-working-dir/TestResults", false, dotnetpublish.Redactor{})
+				Expect(err).To(MatchError("failed to test tests/Unit/Unit.csproj: failed to execute 'dotnet test': exit status 1"))
+
+				Expect(executions).To(HaveLen(2))
+				Expect(executions[1].Args).To(Equal([]string{"build-server", "shutdown"}))
+
+				Expect(buffer.String()).To(ContainLines(
+					"      Failed!  - Failed: 1, Passed: 2",
+					"      Failed after 1s",
+				))
+			})
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package dotnetpublish
+
+import (
+	"path"
+	"strings"
+)
+
+// execCommandPrograms returns the programs invoked by an Exec command. The
+// command is split into the simple commands separated by the &&, ||, ;, | and
+// & operators or by new lines, and the program of each of them is the first
+// word that is not a variable assignment. Paths and Windows extensions are
+// stripped from the program names.
+func execCommandPrograms(command string) []string {
+	var programs []string
+	for _, segment := range splitExecCommand(command) {
+		for _, word := range segment {
+			if isVariableAssignment(word) {
+				continue
+			}
+
+			program := path.Base(strings.ReplaceAll(word, `\`, "/"))
+			for _, extension := range []string{".cmd", ".exe", ".ps1"} {
+				if strings.HasSuffix(strings.ToLower(program), extension) {
+					program = program[:len(program)-len(extension)]
+					break
+				}
+			}
+
+			programs = append(programs, strings.ToLower(program))
+			break
+		}
+	}
+
+	return programs
+}
+
+// splitExecCommand splits a command line into the words of its simple
+// commands, honoring single and double quotes.
+func splitExecCommand(command string) [][]string {
+	var (
+		segments [][]string
+		words    []string
+		word     strings.Builder
+		inWord   bool
+		quote    rune
+	)
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	endSegment := func() {
+		endWord()
+		if len(words) > 0 {
+			segments = append(segments, words)
+			words = nil
+		}
+	}
+
+	for _, c := range command {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				continue
+			}
+			word.WriteRune(c)
+
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+
+		case c == '&' || c == '|' || c == ';' || c == '\n' || c == '\r':
+			endSegment()
+
+		case c == ' ' || c == '\t':
+			endWord()
+
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	endSegment()
+
+	return segments
+}
+
+func isVariableAssignment(word string) bool {
+	name, _, found := strings.Cut(word, "=")
+	if !found || name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,42 @@
+package fakes
+
+import (
+	"sync"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+)
+
+type HookProcess struct {
+	ExecuteCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Name           string
+			Command        string
+			WorkingDir     string
+			NugetCachePath string
+			OutputPath     string
+			Redactor       dotnetpublish.Redactor
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string, string, string, string, dotnetpublish.Redactor) error
+	}
+}
+
+func (f *HookProcess) Execute(param1 string, param2 string, param3 string, param4 string, param5 string, param6 dotnetpublish.Redactor) error {
+	f.ExecuteCall.mutex.Lock()
+	defer f.ExecuteCall.mutex.Unlock()
+	f.ExecuteCall.CallCount++
+	f.ExecuteCall.Receives.Name = param1
+	f.ExecuteCall.Receives.Command = param2
+	f.ExecuteCall.Receives.WorkingDir = param3
+	f.ExecuteCall.Receives.NugetCachePath = param4
+	f.ExecuteCall.Receives.OutputPath = param5
+	f.ExecuteCall.Receives.Redactor = param6
+	if f.ExecuteCall.Stub != nil {
+		return f.ExecuteCall.Stub(param1, param2, param3, param4, param5, param6)
+	}
+	return f.ExecuteCall.Returns.Error
+}
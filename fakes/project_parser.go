@@ -1,97 +1,135 @@
 package fakes
 
-import "sync"
+import (
+	"sync"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+)
 
 type ProjectParser struct {
 	FindProjectFileCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Root string
+			Path    string
+			RootDir string
+			Name    string
 		}
 		Returns struct {
 			String string
 			Error  error
 		}
-		Stub func(string) (string, error)
+		Stub func(string, string, string) (string, error)
 	}
-	NPMIsRequiredCall struct {
+	FindTestProjectsCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Path string
+			Path    string
+			RootDir string
 		}
 		Returns struct {
-			Bool  bool
-			Error error
+			StringSlice []string
+			Error       error
 		}
-		Stub func(string) (bool, error)
+		Stub func(string, string) ([]string, error)
 	}
-	NodeIsRequiredCall struct {
+	ParseGlobalJSONCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Path string
+			Path    string
+			RootDir string
 		}
 		Returns struct {
-			Bool  bool
-			Error error
+			GlobalJSON dotnetpublish.GlobalJSON
+			Error      error
 		}
-		Stub func(string) (bool, error)
+		Stub func(string, string) (dotnetpublish.GlobalJSON, error)
 	}
-	ParseVersionCall struct {
+	ParseProjectCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Path    string
-			RootDir string
+			Path             string
+			RootDir          string
+			GlobalProperties map[string]string
 		}
 		Returns struct {
-			String string
-			Error  error
+			ProjectModel dotnetpublish.ProjectModel
+			Error        error
 		}
-		Stub func(string, string) (string, error)
+		Stub func(string, string, map[string]string) (dotnetpublish.ProjectModel, error)
+	}
+	ParsePublishProfileCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+			Name string
+		}
+		Returns struct {
+			PublishProfile dotnetpublish.PublishProfile
+			Error          error
+		}
+		Stub func(string, string) (dotnetpublish.PublishProfile, error)
 	}
 }
 
-func (f *ProjectParser) FindProjectFile(param1 string) (string, error) {
+func (f *ProjectParser) FindProjectFile(param1 string, param2 string, param3 string) (string, error) {
 	f.FindProjectFileCall.mutex.Lock()
 	defer f.FindProjectFileCall.mutex.Unlock()
 	f.FindProjectFileCall.CallCount++
-	f.FindProjectFileCall.Receives.Root = param1
+	f.FindProjectFileCall.Receives.Path = param1
+	f.FindProjectFileCall.Receives.RootDir = param2
+	f.FindProjectFileCall.Receives.Name = param3
 	if f.FindProjectFileCall.Stub != nil {
-		return f.FindProjectFileCall.Stub(param1)
+		return f.FindProjectFileCall.Stub(param1, param2, param3)
 	}
 	return f.FindProjectFileCall.Returns.String, f.FindProjectFileCall.Returns.Error
 }
-func (f *ProjectParser) NPMIsRequired(param1 string) (bool, error) {
-	f.NPMIsRequiredCall.mutex.Lock()
-	defer f.NPMIsRequiredCall.mutex.Unlock()
-	f.NPMIsRequiredCall.CallCount++
-	f.NPMIsRequiredCall.Receives.Path = param1
-	if f.NPMIsRequiredCall.Stub != nil {
-		return f.NPMIsRequiredCall.Stub(param1)
+func (f *ProjectParser) FindTestProjects(param1 string, param2 string) ([]string, error) {
+	f.FindTestProjectsCall.mutex.Lock()
+	defer f.FindTestProjectsCall.mutex.Unlock()
+	f.FindTestProjectsCall.CallCount++
+	f.FindTestProjectsCall.Receives.Path = param1
+	f.FindTestProjectsCall.Receives.RootDir = param2
+	if f.FindTestProjectsCall.Stub != nil {
+		return f.FindTestProjectsCall.Stub(param1, param2)
+	}
+	return f.FindTestProjectsCall.Returns.StringSlice, f.FindTestProjectsCall.Returns.Error
+}
+func (f *ProjectParser) ParseGlobalJSON(param1 string, param2 string) (dotnetpublish.GlobalJSON, error) {
+	f.ParseGlobalJSONCall.mutex.Lock()
+	defer f.ParseGlobalJSONCall.mutex.Unlock()
+	f.ParseGlobalJSONCall.CallCount++
+	f.ParseGlobalJSONCall.Receives.Path = param1
+	f.ParseGlobalJSONCall.Receives.RootDir = param2
+	if f.ParseGlobalJSONCall.Stub != nil {
+		return f.ParseGlobalJSONCall.Stub(param1, param2)
 	}
-	return f.NPMIsRequiredCall.Returns.Bool, f.NPMIsRequiredCall.Returns.Error
+	return f.ParseGlobalJSONCall.Returns.GlobalJSON, f.ParseGlobalJSONCall.Returns.Error
 }
-func (f *ProjectParser) NodeIsRequired(param1 string) (bool, error) {
-	f.NodeIsRequiredCall.mutex.Lock()
-	defer f.NodeIsRequiredCall.mutex.Unlock()
-	f.NodeIsRequiredCall.CallCount++
-	f.NodeIsRequiredCall.Receives.Path = param1
-	if f.NodeIsRequiredCall.Stub != nil {
-		return f.NodeIsRequiredCall.Stub(param1)
+func (f *ProjectParser) ParseProject(param1 string, param2 string, param3 map[string]string) (dotnetpublish.ProjectModel, error) {
+	f.ParseProjectCall.mutex.Lock()
+	defer f.ParseProjectCall.mutex.Unlock()
+	f.ParseProjectCall.CallCount++
+	f.ParseProjectCall.Receives.Path = param1
+	f.ParseProjectCall.Receives.RootDir = param2
+	f.ParseProjectCall.Receives.GlobalProperties = param3
+	if f.ParseProjectCall.Stub != nil {
+		return f.ParseProjectCall.Stub(param1, param2, param3)
 	}
-	return f.NodeIsRequiredCall.Returns.Bool, f.NodeIsRequiredCall.Returns.Error
+	return f.ParseProjectCall.Returns.ProjectModel, f.ParseProjectCall.Returns.Error
 }
-func (f *ProjectParser) ParseVersion(param1 string, param2 string) (string, error) {
-	f.ParseVersionCall.mutex.Lock()
-	defer f.ParseVersionCall.mutex.Unlock()
-	f.ParseVersionCall.CallCount++
-	f.ParseVersionCall.Receives.Path = param1
-	f.ParseVersionCall.Receives.RootDir = param2
-	if f.ParseVersionCall.Stub != nil {
-		return f.ParseVersionCall.Stub(param1, param2)
+func (f *ProjectParser) ParsePublishProfile(param1 string, param2 string) (dotnetpublish.PublishProfile, error) {
+	f.ParsePublishProfileCall.mutex.Lock()
+	defer f.ParsePublishProfileCall.mutex.Unlock()
+	f.ParsePublishProfileCall.CallCount++
+	f.ParsePublishProfileCall.Receives.Path = param1
+	f.ParsePublishProfileCall.Receives.Name = param2
+	if f.ParsePublishProfileCall.Stub != nil {
+		return f.ParsePublishProfileCall.Stub(param1, param2)
 	}
-	return f.ParseVersionCall.Returns.String, f.ParseVersionCall.Returns.Error
+	return f.ParsePublishProfileCall.Returns.PublishProfile, f.ParsePublishProfileCall.Returns.Error
 }
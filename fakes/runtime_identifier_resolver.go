@@ -0,0 +1,29 @@
+package fakes
+
+import "sync"
+
+type RuntimeIdentifierResolver struct {
+	ResolveCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Override string
+		}
+		Returns struct {
+			String string
+			Error  error
+		}
+		Stub func(string) (string, error)
+	}
+}
+
+func (f *RuntimeIdentifierResolver) Resolve(param1 string) (string, error) {
+	f.ResolveCall.mutex.Lock()
+	defer f.ResolveCall.mutex.Unlock()
+	f.ResolveCall.CallCount++
+	f.ResolveCall.Receives.Override = param1
+	if f.ResolveCall.Stub != nil {
+		return f.ResolveCall.Stub(param1)
+	}
+	return f.ResolveCall.Returns.String, f.ResolveCall.Returns.Error
+}
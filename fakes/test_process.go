@@ -0,0 +1,42 @@
+package fakes
+
+import (
+	"sync"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+)
+
+type TestProcess struct {
+	ExecuteCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			WorkingDir     string
+			NugetCachePath string
+			ProjectPaths   []string
+			ResultsPath    string
+			Debug          bool
+			Redactor       dotnetpublish.Redactor
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string, []string, string, bool, dotnetpublish.Redactor) error
+	}
+}
+
+func (f *TestProcess) Execute(param1 string, param2 string, param3 []string, param4 string, param5 bool, param6 dotnetpublish.Redactor) error {
+	f.ExecuteCall.mutex.Lock()
+	defer f.ExecuteCall.mutex.Unlock()
+	f.ExecuteCall.CallCount++
+	f.ExecuteCall.Receives.WorkingDir = param1
+	f.ExecuteCall.Receives.NugetCachePath = param2
+	f.ExecuteCall.Receives.ProjectPaths = param3
+	f.ExecuteCall.Receives.ResultsPath = param4
+	f.ExecuteCall.Receives.Debug = param5
+	f.ExecuteCall.Receives.Redactor = param6
+	if f.ExecuteCall.Stub != nil {
+		return f.ExecuteCall.Stub(param1, param2, param3, param4, param5, param6)
+	}
+	return f.ExecuteCall.Returns.Error
+}
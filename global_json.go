@@ -0,0 +1,160 @@
+package dotnetpublish
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// GlobalJSON holds the SDK settings of a global.json file. Path is empty when
+// no global.json file was found.
+type GlobalJSON struct {
+	Path string
+	SDK  struct {
+		Version         string `json:"version"`
+		RollForward     string `json:"rollForward"`
+		AllowPrerelease bool   `json:"allowPrerelease"`
+	} `json:"sdk"`
+}
+
+var rollForwardPolicies = []string{
+	"patch", "latestPatch",
+	"feature", "latestFeature",
+	"minor", "latestMinor",
+	"major", "latestMajor",
+	"disable",
+}
+
+func (g GlobalJSON) validate() error {
+	if g.SDK.Version != "" {
+		_, err := semver.NewVersion(g.SDK.Version)
+		if err != nil {
+			return fmt.Errorf("invalid sdk version %q: %w", g.SDK.Version, err)
+		}
+	}
+
+	if g.SDK.RollForward != "" && g.policy() == "" {
+		return fmt.Errorf("invalid sdk rollForward policy %q: must be one of %s", g.SDK.RollForward, strings.Join(rollForwardPolicies, ", "))
+	}
+
+	return nil
+}
+
+func (g GlobalJSON) policy() string {
+	if g.SDK.RollForward == "" {
+		return "latestPatch"
+	}
+
+	for _, policy := range rollForwardPolicies {
+		if strings.EqualFold(policy, g.SDK.RollForward) {
+			return policy
+		}
+	}
+
+	return ""
+}
+
+// SDKConstraint translates the pinned SDK version and its rollForward policy
+// into a version constraint. It returns an empty string when no SDK version is
+// pinned.
+func (g GlobalJSON) SDKConstraint(prerelease bool) string {
+	if g.SDK.Version == "" {
+		return ""
+	}
+
+	version, err := semver.NewVersion(g.SDK.Version)
+	if err != nil {
+		return ""
+	}
+
+	lower := version.String()
+	if g.policy() == "disable" {
+		return lower
+	}
+
+	// SDK versions encode the feature band in the hundreds of the patch
+	// number, e.g. 8.0.1xx, 8.0.2xx.
+	var upper string
+	switch g.policy() {
+	case "patch", "latestPatch":
+		upper = fmt.Sprintf("%d.%d.%d", version.Major(), version.Minor(), (version.Patch()/100+1)*100)
+	case "feature", "latestFeature":
+		upper = fmt.Sprintf("%d.%d.0", version.Major(), version.Minor()+1)
+	case "minor", "latestMinor":
+		upper = fmt.Sprintf("%d.0.0", version.Major()+1)
+	}
+
+	if prerelease {
+		if version.Prerelease() == "" {
+			lower += "-0"
+		}
+
+		if upper != "" {
+			upper += "-0"
+		}
+	}
+
+	if upper == "" {
+		return fmt.Sprintf(">= %s", lower)
+	}
+
+	return fmt.Sprintf(">= %s, < %s", lower, upper)
+}
+
+// standardizeJSON turns JSON with comments and trailing commas, as accepted by
+// the .NET host, into standard JSON. Comments and trailing commas are replaced
+// by spaces, so that offsets in decoding errors still point at the original
+// content.
+func standardizeJSON(content []byte) []byte {
+	result := append([]byte{}, content...)
+
+	// lastComma is the offset of the last comma outside of a string that has
+	// only been followed by whitespace and comments since.
+	lastComma := -1
+	for i := 0; i < len(result); i++ {
+		switch c := result[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(result) && result[i] != '"'; i++ {
+				if result[i] == '\\' {
+					i++
+				}
+			}
+
+		case c == '/' && i+1 < len(result) && result[i+1] == '/':
+			for ; i < len(result) && result[i] != '\n'; i++ {
+				result[i] = ' '
+			}
+
+		case c == '/' && i+1 < len(result) && result[i+1] == '*':
+			end := bytes.Index(result[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(result)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				if result[i] != '\n' {
+					result[i] = ' '
+				}
+			}
+			i--
+
+		case c == ',':
+			lastComma = i
+
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				result[lastComma] = ' '
+			}
+			lastComma = -1
+
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			lastComma = -1
+		}
+	}
+
+	return result
+}
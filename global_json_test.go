@@ -0,0 +1,79 @@
+package dotnetpublish_test
+
+import (
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testGlobalJSON(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		globalJSON dotnetpublish.GlobalJSON
+	)
+
+	it.Before(func() {
+		globalJSON = dotnetpublish.GlobalJSON{}
+		globalJSON.SDK.Version = "8.0.204"
+	})
+
+	context("SDKConstraint", func() {
+		it("rolls forward to the latest patch by default", func() {
+			Expect(globalJSON.SDKConstraint(false)).To(Equal(">= 8.0.204, < 8.0.300"))
+		})
+
+		for policy, constraint := range map[string]string{
+			"patch":         ">= 8.0.204, < 8.0.300",
+			"latestPatch":   ">= 8.0.204, < 8.0.300",
+			"feature":       ">= 8.0.204, < 8.1.0",
+			"latestFeature": ">= 8.0.204, < 8.1.0",
+			"minor":         ">= 8.0.204, < 9.0.0",
+			"latestMinor":   ">= 8.0.204, < 9.0.0",
+			"major":         ">= 8.0.204",
+			"latestMajor":   ">= 8.0.204",
+			"disable":       "8.0.204",
+		} {
+			context("when rollForward is "+policy, func() {
+				it.Before(func() {
+					globalJSON.SDK.RollForward = policy
+				})
+
+				it("returns the matching constraint", func() {
+					Expect(globalJSON.SDKConstraint(false)).To(Equal(constraint))
+				})
+			})
+		}
+
+		context("when prereleases are allowed", func() {
+			it("includes prereleases in the constraint", func() {
+				Expect(globalJSON.SDKConstraint(true)).To(Equal(">= 8.0.204-0, < 8.0.300-0"))
+			})
+
+			context("when the pinned version is a prerelease", func() {
+				it.Before(func() {
+					globalJSON.SDK.Version = "9.0.100-preview.1"
+					globalJSON.SDK.RollForward = "latestMajor"
+				})
+
+				it("keeps the pinned prerelease as the lower bound", func() {
+					Expect(globalJSON.SDKConstraint(true)).To(Equal(">= 9.0.100-preview.1"))
+				})
+			})
+		})
+
+		context("when no version is pinned", func() {
+			it.Before(func() {
+				globalJSON.SDK.Version = ""
+				globalJSON.SDK.RollForward = "latestMajor"
+			})
+
+			it("returns an empty constraint", func() {
+				Expect(globalJSON.SDKConstraint(false)).To(BeEmpty())
+			})
+		})
+	})
+}
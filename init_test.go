@@ -13,7 +13,17 @@ func TestUnitDotnetPublish(t *testing.T) {
 	suite("Detect", testDetect)
 	suite("DotnetPublishProcess", testDotnetPublishProcess)
 	suite("DotnetSourceRemover", testDotnetSourceRemover)
+	suite("DotnetTestProcess", testDotnetTestProcess)
+	suite("GlobalJSON", testGlobalJSON)
+	suite("LinuxRuntimeIdentifierResolver", testLinuxRuntimeIdentifierResolver)
+	suite("MSBuildDiagnostics", testMSBuildDiagnostics)
+	suite("MSBuildProperties", testMSBuildProperties)
 	suite("ProjectFileParser", testProjectFileParser)
+	suite("ProcessTreeExecutable", testProcessTreeExecutable)
+	suite("ProjectModel", testProjectModel)
+	suite("PublishFlags", testPublishFlags)
+	suite("Redactor", testRedactor)
+	suite("ShellHookProcess", testShellHookProcess)
 	suite("Symlinker", testSymlinker)
 	suite("OutputSlicer", testOutputSlicer)
 	suite.Run(t)
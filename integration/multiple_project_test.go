@@ -76,5 +76,36 @@ func testMultipleProject(t *testing.T, context spec.G, it spec.S) {
 
 			Eventually(container).Should(Serve(ContainSubstring("Hello, I'm a string!")).OnPort(8080))
 		})
+
+		it("should build a working OCI image for the executable project referenced by the solution file", func() {
+			var err error
+			source, err = occam.Source(filepath.Join("testdata", "multiple_projects_msbuild"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var logs fmt.Stringer
+			image, logs, err = pack.WithNoColor().Build.
+				WithBuildpacks(
+					icuBuildpack,
+					dotnetCoreSDKBuildpack,
+					buildpack,
+					dotnetCoreAspNetRuntimeBuildpack,
+					dotnetExecuteBuildpack,
+				).
+				Execute(name, source)
+			Expect(err).NotTo(HaveOccurred(), logs.String())
+
+			Expect(logs).To(ContainLines(
+				MatchRegexp(`    Running 'dotnet publish \/workspace\/asp_web_app\/asp_web_app\.csproj --configuration Release`),
+			))
+
+			container, err = docker.Container.Run.
+				WithEnv(map[string]string{"PORT": "8080"}).
+				WithPublish("8080").
+				WithPublishAll().
+				Execute(image.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(container).Should(Serve(ContainSubstring("Hello, I'm a string!")).OnPort(8080))
+		})
 	})
 }
@@ -67,7 +67,10 @@ func testVisualBasic(t *testing.T, context spec.G, it spec.S) {
 			Expect(logs).To(ContainLines(
 				MatchRegexp(fmt.Sprintf(`%s \d+\.\d+\.\d+`, buildpackInfo.Buildpack.Name)),
 				"  Executing build process",
-				MatchRegexp(`    Running 'dotnet publish \/workspace --configuration Release --runtime linux-x64 --self-contained false --output \/tmp\/dotnet-publish-output\d+'`),
+				MatchRegexp(`    Running 'dotnet restore \/workspace\/visual_basic\.vbproj -p:Configuration=Release --runtime linux-x64 -p:SelfContained=false --verbosity minimal -clp:DisableConsoleColor'`),
+			))
+			Expect(logs).To(ContainLines(
+				MatchRegexp(`    Running 'dotnet publish \/workspace\/visual_basic\.vbproj --configuration Release --runtime linux-x64 --self-contained false --output \/tmp\/dotnet-publish-output\d+ --verbosity minimal -clp:DisableConsoleColor --no-restore'`),
 			))
 			Expect(logs).To(ContainLines(
 				MatchRegexp(`      Completed in ([0-9]*(\.[0-9]*)?[a-z]+)+`),
@@ -13,5 +13,7 @@ func TestUnitInternal(t *testing.T) {
 	suite("RuntimeTargets", testRuntimeTargets)
 	suite("RuntimeDependencies", testRuntimeDependencies)
 	suite("Dependencies", testDependencies)
+	suite("MSBuildProject", testMSBuildProject)
+	suite("Properties", testProperties)
 	suite.Run(t)
 }
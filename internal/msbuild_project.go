@@ -0,0 +1,497 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MSBuildProject holds the result of evaluating a project file, including the
+// files it imports.
+type MSBuildProject struct {
+	Path       string
+	Sdk        string
+	Properties Properties
+	Items      []MSBuildItem
+	Targets    []MSBuildTarget
+	Imports    []string
+}
+
+type MSBuildItem struct {
+	Type     string
+	Include  string
+	Metadata map[string]string
+}
+
+// MSBuildTarget holds the Exec tasks of a target. Targets are not executed, so
+// their conditions are not evaluated.
+type MSBuildTarget struct {
+	Name  string
+	Execs []MSBuildExec
+}
+
+type MSBuildExec struct {
+	Command          string
+	WorkingDirectory string
+}
+
+type msbuildElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr       `xml:",any,attr"`
+	Content  string           `xml:",chardata"`
+	Children []msbuildElement `xml:",any"`
+}
+
+func (e msbuildElement) attr(name string) string {
+	for _, attr := range e.Attrs {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// msbuildDeferredElement is an item group or target along with the
+// MSBuildThisFile properties of the file that declares it, as those are
+// evaluated once all of the properties are known.
+type msbuildDeferredElement struct {
+	element  msbuildElement
+	thisFile map[string]string
+}
+
+type msbuildEvaluator struct {
+	properties Properties
+	readOnly   map[string]bool
+	imported   map[string]bool
+	imports    []string
+	sdk        string
+	itemGroups []msbuildDeferredElement
+	targets    []msbuildDeferredElement
+}
+
+// EvaluateMSBuildProject evaluates the project file at the given path the way
+// MSBuild does, minus the SDK imports: environment variables, the nearest
+// Directory.Build.props and Directory.Build.targets files up to rootDir,
+// <Import> elements and conditions are all taken into account. Global
+// properties can not be overridden by the project. As in MSBuild, items and
+// targets are evaluated after all of the properties.
+func EvaluateMSBuildProject(path, rootDir string, globalProperties map[string]string) (MSBuildProject, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return MSBuildProject{}, err
+	}
+
+	evaluator := msbuildEvaluator{
+		properties: Properties{},
+		readOnly:   map[string]bool{},
+		imported:   map[string]bool{path: true},
+	}
+
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		if propertyNameRe.MatchString(name) {
+			evaluator.properties.Set(name, value)
+		}
+	}
+
+	extension := filepath.Ext(path)
+	reserved := map[string]string{
+		"MSBuildProjectDirectory": filepath.Dir(path),
+		"MSBuildProjectFile":      filepath.Base(path),
+		"MSBuildProjectFullPath":  path,
+		"MSBuildProjectName":      strings.TrimSuffix(filepath.Base(path), extension),
+		"MSBuildProjectExtension": extension,
+	}
+
+	for name, value := range globalProperties {
+		reserved[name] = value
+	}
+
+	for name, value := range reserved {
+		evaluator.properties.Set(name, value)
+		evaluator.readOnly[strings.ToLower(name)] = true
+	}
+
+	// The SDK props default the Platform before the project is evaluated, so
+	// that conditions such as '$(Configuration)|$(Platform)' == 'Release|AnyCPU'
+	// hold.
+	if evaluator.properties.Get("Platform") == "" {
+		evaluator.properties.Set("Platform", "AnyCPU")
+	}
+
+	if !strings.EqualFold(evaluator.properties.Get("ImportDirectoryBuildProps"), "false") {
+		err = evaluator.importFileAbove(filepath.Dir(path), rootDir, "Directory.Build.props")
+		if err != nil {
+			return MSBuildProject{}, err
+		}
+	}
+
+	err = evaluator.evaluateFile(path, "project file")
+	if err != nil {
+		return MSBuildProject{}, err
+	}
+
+	if !strings.EqualFold(evaluator.properties.Get("ImportDirectoryBuildTargets"), "false") {
+		err = evaluator.importFileAbove(filepath.Dir(path), rootDir, "Directory.Build.targets")
+		if err != nil {
+			return MSBuildProject{}, err
+		}
+	}
+
+	return MSBuildProject{
+		Path:       path,
+		Sdk:        evaluator.sdk,
+		Properties: evaluator.properties,
+		Items:      evaluator.evaluateItems(),
+		Targets:    evaluator.evaluateTargets(),
+		Imports:    evaluator.imports,
+	}, nil
+}
+
+func (e *msbuildEvaluator) evaluateItems() []MSBuildItem {
+	var items []MSBuildItem
+	for _, group := range e.itemGroups {
+		restore := e.setThisFile(group.thisFile)
+
+		if e.condition(group.element) {
+			for _, item := range group.element.Children {
+				if !e.condition(item) {
+					continue
+				}
+
+				items = e.evaluateItem(items, item)
+			}
+		}
+
+		restore()
+	}
+
+	return items
+}
+
+func (e *msbuildEvaluator) evaluateItem(items []MSBuildItem, element msbuildElement) []MSBuildItem {
+	itemType := element.XMLName.Local
+
+	metadata := map[string]string{}
+	for _, attr := range element.Attrs {
+		switch attr.Name.Local {
+		case "Include", "Exclude", "Remove", "Update", "Condition", "KeepMetadata", "RemoveMetadata", "KeepDuplicates":
+		default:
+			metadata[attr.Name.Local] = e.properties.Expand(attr.Value)
+		}
+	}
+
+	for _, child := range element.Children {
+		if e.condition(child) {
+			metadata[child.XMLName.Local] = e.properties.Expand(strings.TrimSpace(child.Content))
+		}
+	}
+
+	if remove := element.attr("Remove"); remove != "" {
+		return removeItems(items, itemType, e.splitItemSpec(remove))
+	}
+
+	if update := element.attr("Update"); update != "" {
+		for _, include := range e.splitItemSpec(update) {
+			for i, item := range items {
+				if item.Type == itemType && strings.EqualFold(item.Include, include) {
+					for name, value := range metadata {
+						items[i].Metadata[name] = value
+					}
+				}
+			}
+		}
+		return items
+	}
+
+	excluded := e.splitItemSpec(element.attr("Exclude"))
+	for _, include := range e.splitItemSpec(element.attr("Include")) {
+		if containsFold(excluded, include) {
+			continue
+		}
+
+		itemMetadata := map[string]string{}
+		for name, value := range metadata {
+			itemMetadata[name] = value
+		}
+
+		items = append(items, MSBuildItem{Type: itemType, Include: include, Metadata: itemMetadata})
+	}
+
+	return items
+}
+
+func (e *msbuildEvaluator) splitItemSpec(spec string) []string {
+	var values []string
+	for _, value := range strings.Split(e.properties.Expand(spec), ";") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func removeItems(items []MSBuildItem, itemType string, removed []string) []MSBuildItem {
+	var kept []MSBuildItem
+	for _, item := range items {
+		if item.Type == itemType && containsFold(removed, item.Include) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateTargets collects the targets of the project. A target overrides any
+// target with the same name that was declared before it.
+func (e *msbuildEvaluator) evaluateTargets() []MSBuildTarget {
+	var targets []MSBuildTarget
+	indexes := map[string]int{}
+	for _, deferred := range e.targets {
+		restore := e.setThisFile(deferred.thisFile)
+
+		target := MSBuildTarget{Name: deferred.element.attr("Name")}
+		for _, task := range deferred.element.Children {
+			if task.XMLName.Local == "Exec" {
+				target.Execs = append(target.Execs, MSBuildExec{
+					Command:          e.properties.Expand(task.attr("Command")),
+					WorkingDirectory: e.properties.Expand(task.attr("WorkingDirectory")),
+				})
+			}
+		}
+
+		restore()
+
+		if i, ok := indexes[strings.ToLower(target.Name)]; ok {
+			targets[i] = target
+			continue
+		}
+
+		indexes[strings.ToLower(target.Name)] = len(targets)
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+func (e *msbuildEvaluator) importFileAbove(dir, rootDir, name string) error {
+	rootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return err
+	}
+
+	for ; ; dir = filepath.Dir(dir) {
+		path := filepath.Join(dir, name)
+		_, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err == nil {
+			return e.importFile(path)
+		}
+
+		if dir == rootDir || filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
+
+func (e *msbuildEvaluator) importFile(path string) error {
+	if e.imported[path] {
+		return nil
+	}
+	e.imported[path] = true
+	e.imports = append(e.imports, path)
+
+	return e.evaluateFile(path, filepath.Base(path))
+}
+
+func (e *msbuildEvaluator) evaluateFile(path, fileDescription string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", fileDescription, err)
+	}
+
+	var project msbuildElement
+	err = xml.Unmarshal(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), &project)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", fileDescription, err)
+	}
+
+	if path == e.properties.Get("MSBuildProjectFullPath") {
+		e.sdk = project.attr("Sdk")
+	}
+
+	extension := filepath.Ext(path)
+	restore := e.setThisFile(map[string]string{
+		"MSBuildThisFile":          filepath.Base(path),
+		"MSBuildThisFileDirectory": ensureTrailingSlash(filepath.Dir(path)),
+		"MSBuildThisFileFullPath":  path,
+		"MSBuildThisFileName":      strings.TrimSuffix(filepath.Base(path), extension),
+		"MSBuildThisFileExtension": extension,
+	})
+
+	err = e.evaluateElements(path, project.Children)
+	if err != nil {
+		return err
+	}
+
+	restore()
+
+	return nil
+}
+
+// setThisFile sets the MSBuildThisFile properties, which refer to the file
+// being evaluated, and returns a function that restores their previous values.
+func (e *msbuildEvaluator) setThisFile(thisFile map[string]string) func() {
+	previous := map[string]string{}
+	for name, value := range thisFile {
+		previous[name] = e.properties.Get(name)
+		e.properties.Set(name, value)
+		e.readOnly[strings.ToLower(name)] = true
+	}
+
+	return func() {
+		for name, value := range previous {
+			e.properties.Set(name, value)
+		}
+	}
+}
+
+func (e *msbuildEvaluator) thisFile() map[string]string {
+	thisFile := map[string]string{}
+	for _, name := range []string{"MSBuildThisFile", "MSBuildThisFileDirectory", "MSBuildThisFileFullPath", "MSBuildThisFileName", "MSBuildThisFileExtension"} {
+		thisFile[name] = e.properties.Get(name)
+	}
+	return thisFile
+}
+
+func (e *msbuildEvaluator) evaluateElements(path string, elements []msbuildElement) error {
+	for _, element := range elements {
+		switch element.XMLName.Local {
+		case "ItemGroup":
+			e.itemGroups = append(e.itemGroups, msbuildDeferredElement{element: element, thisFile: e.thisFile()})
+			continue
+
+		case "Target":
+			e.targets = append(e.targets, msbuildDeferredElement{element: element, thisFile: e.thisFile()})
+			continue
+		}
+
+		if !e.condition(element) {
+			continue
+		}
+
+		switch element.XMLName.Local {
+		case "Sdk":
+			if e.sdk == "" && path == e.properties.Get("MSBuildProjectFullPath") {
+				e.sdk = element.attr("Name")
+			}
+
+		case "PropertyGroup":
+			for _, property := range element.Children {
+				if !e.condition(property) || e.readOnly[strings.ToLower(property.XMLName.Local)] {
+					continue
+				}
+
+				e.properties.Set(property.XMLName.Local, e.properties.Expand(strings.TrimSpace(property.Content)))
+			}
+
+		case "ImportGroup":
+			for _, child := range element.Children {
+				if child.XMLName.Local == "Import" && e.condition(child) {
+					err := e.importProject(path, child)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+		case "Import":
+			err := e.importProject(path, element)
+			if err != nil {
+				return err
+			}
+
+		case "Choose":
+			for _, option := range element.Children {
+				if option.XMLName.Local == "Otherwise" || (option.XMLName.Local == "When" && e.condition(option)) {
+					err := e.evaluateElements(path, option.Children)
+					if err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// importProject evaluates the files matched by the Project attribute of an
+// <Import> element. Imports that can not be found, including SDK imports, are
+// skipped as they can only be resolved by MSBuild itself.
+func (e *msbuildEvaluator) importProject(path string, element msbuildElement) error {
+	if element.attr("Sdk") != "" {
+		return nil
+	}
+
+	project := strings.TrimSpace(e.properties.Expand(element.attr("Project")))
+	if project == "" {
+		return nil
+	}
+
+	project = filepath.FromSlash(strings.ReplaceAll(project, `\`, "/"))
+	if !filepath.IsAbs(project) {
+		project = filepath.Join(filepath.Dir(path), project)
+	}
+
+	matches := []string{filepath.Clean(project)}
+	if strings.ContainsAny(project, "*?") {
+		var err error
+		matches, err = filepath.Glob(project)
+		if err != nil {
+			return fmt.Errorf("failed to resolve import %q: %w", element.attr("Project"), err)
+		}
+		sort.Strings(matches)
+	}
+
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		err = e.importFile(match)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// condition evaluates the Condition attribute of an element. Conditions that
+// can not be evaluated are considered true.
+func (e *msbuildEvaluator) condition(element msbuildElement) bool {
+	result, err := e.properties.EvaluateCondition(element.attr("Condition"))
+	if err != nil {
+		return true
+	}
+	return result
+}
@@ -0,0 +1,330 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/dotnet-publish/internal"
+	"github.com/sclevine/spec"
+)
+
+func testMSBuildProject(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(root, "src", "app"), os.ModePerm)).To(Succeed())
+		path = filepath.Join(root, "src", "app", "app.csproj")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	it("evaluates properties in document order", func() {
+		Expect(os.WriteFile(path, []byte(`
+			<Project Sdk="Microsoft.NET.Sdk">
+			  <PropertyGroup>
+			    <Major>8</Major>
+			    <TargetFramework>net$(Major).0</TargetFramework>
+			    <Output>$(MSBuildProjectName)/$(Configuration)</Output>
+			  </PropertyGroup>
+			  <PropertyGroup Condition="'$(Configuration)' == 'Debug'">
+			    <Major>6</Major>
+			  </PropertyGroup>
+			  <PropertyGroup>
+			    <RuntimeIdentifier Condition="'$(Major)' == '8'">linux-x64</RuntimeIdentifier>
+			  </PropertyGroup>
+			</Project>
+		`), 0600)).To(Succeed())
+
+		project, err := internal.EvaluateMSBuildProject(path, root, map[string]string{"Configuration": "Release"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(project.Path).To(Equal(path))
+		Expect(project.Properties.Get("TargetFramework")).To(Equal("net8.0"))
+		Expect(project.Properties.Get("Output")).To(Equal("app/Release"))
+		Expect(project.Properties.Get("RuntimeIdentifier")).To(Equal("linux-x64"))
+		Expect(project.Properties.Get("MSBuildProjectDirectory")).To(Equal(filepath.Join(root, "src", "app")))
+		Expect(project.Imports).To(BeEmpty())
+	})
+
+	it("does not let the project override global properties", func() {
+		Expect(os.WriteFile(path, []byte(`
+			<Project>
+			  <PropertyGroup>
+			    <Configuration>Debug</Configuration>
+			    <MSBuildProjectName>other</MSBuildProjectName>
+			  </PropertyGroup>
+			</Project>
+		`), 0600)).To(Succeed())
+
+		project, err := internal.EvaluateMSBuildProject(path, root, map[string]string{"Configuration": "Release"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(project.Properties.Get("Configuration")).To(Equal("Release"))
+		Expect(project.Properties.Get("MSBuildProjectName")).To(Equal("app"))
+	})
+
+	it("falls back to environment variables", func() {
+		t.Setenv("SOME_VERSION", "1.2.3")
+
+		Expect(os.WriteFile(path, []byte(`
+			<Project>
+			  <PropertyGroup>
+			    <Version>$(SOME_VERSION)</Version>
+			  </PropertyGroup>
+			</Project>
+		`), 0600)).To(Succeed())
+
+		project, err := internal.EvaluateMSBuildProject(path, root, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(project.Properties.Get("Version")).To(Equal("1.2.3"))
+	})
+
+	it("evaluates the chosen branch of a Choose element", func() {
+		Expect(os.WriteFile(path, []byte(`
+			<Project>
+			  <Choose>
+			    <When Condition="'$(Configuration)' == 'Debug'">
+			      <PropertyGroup>
+			        <Optimize>false</Optimize>
+			      </PropertyGroup>
+			    </When>
+			    <Otherwise>
+			      <PropertyGroup>
+			        <Optimize>true</Optimize>
+			      </PropertyGroup>
+			    </Otherwise>
+			  </Choose>
+			</Project>
+		`), 0600)).To(Succeed())
+
+		project, err := internal.EvaluateMSBuildProject(path, root, map[string]string{"Configuration": "Release"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(project.Properties.Get("Optimize")).To(Equal("true"))
+	})
+
+	it("evaluates items and targets after the properties", func() {
+		Expect(os.WriteFile(path, []byte(`
+			<Project Sdk="Microsoft.NET.Sdk.Web">
+			  <ItemGroup>
+			    <PackageReference Include="$(PackagePrefix).Json;$(PackagePrefix).Xml" Version="$(PackageVersion)" />
+			    <PackageReference Include="Other" Condition="'$(IncludeOther)' == 'true'" />
+			    <PackageReference Remove="$(PackagePrefix).Xml" />
+			    <PackageReference Update="$(PackagePrefix).Json" PrivateAssets="all" />
+			  </ItemGroup>
+			  <ItemGroup Condition="'$(Configuration)' == 'Debug'">
+			    <None Include="debug.txt" />
+			  </ItemGroup>
+			  <PropertyGroup>
+			    <PackagePrefix>Some</PackagePrefix>
+			    <PackageVersion>1.0.0</PackageVersion>
+			    <SpaRoot>ClientApp/</SpaRoot>
+			  </PropertyGroup>
+			  <Target Name="Frontend">
+			    <Exec Command="npm ci" WorkingDirectory="$(SpaRoot)" />
+			  </Target>
+			  <Target Name="Frontend" Condition="'$(Configuration)' == 'Release'">
+			    <Message Text="skipped" />
+			    <Exec Command="npm run build" WorkingDirectory="$(SpaRoot)" />
+			  </Target>
+			</Project>
+		`), 0600)).To(Succeed())
+
+		project, err := internal.EvaluateMSBuildProject(path, root, map[string]string{"Configuration": "Release"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(project.Sdk).To(Equal("Microsoft.NET.Sdk.Web"))
+		Expect(project.Items).To(Equal([]internal.MSBuildItem{
+			{
+				Type:     "PackageReference",
+				Include:  "Some.Json",
+				Metadata: map[string]string{"Version": "1.0.0", "PrivateAssets": "all"},
+			},
+		}))
+		Expect(project.Targets).To(Equal([]internal.MSBuildTarget{
+			{
+				Name:  "Frontend",
+				Execs: []internal.MSBuildExec{{Command: "npm run build", WorkingDirectory: "ClientApp/"}},
+			},
+		}))
+	})
+
+	context("when the project imports other files", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(root, "build"), os.ModePerm)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(root, "build", "common.props"), []byte(`
+				<Project>
+				  <Import Project="$(MSBuildThisFileDirectory)versions.props" />
+				  <PropertyGroup>
+				    <CommonDir>$(MSBuildThisFileDirectory)</CommonDir>
+				    <ThisFile>$(MSBuildThisFile)</ThisFile>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(root, "build", "versions.props"), []byte(`
+				<Project>
+				  <Import Project="common.props" />
+				  <PropertyGroup>
+				    <NetVersion>9.0</NetVersion>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(root, "build", "extra.targets"), []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <Extra>true</Extra>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(path, []byte(`
+				<Project>
+				  <Import Project="..\..\build\common.props" />
+				  <Import Project="missing.props" />
+				  <Import Project="Sdk.props" Sdk="Microsoft.NET.Sdk" />
+				  <ImportGroup Condition="'$(NetVersion)' == '9.0'">
+				    <Import Project="../../build/*.targets" />
+				  </ImportGroup>
+				  <PropertyGroup>
+				    <TargetFramework>net$(NetVersion)</TargetFramework>
+				    <ProjectFile>$(MSBuildThisFile)</ProjectFile>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+		})
+
+		it("evaluates the imported files relative to the importing file", func() {
+			project, err := internal.EvaluateMSBuildProject(path, root, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(project.Properties.Get("TargetFramework")).To(Equal("net9.0"))
+			Expect(project.Properties.Get("CommonDir")).To(Equal(filepath.Join(root, "build") + "/"))
+			Expect(project.Properties.Get("ThisFile")).To(Equal("common.props"))
+			Expect(project.Properties.Get("ProjectFile")).To(Equal("app.csproj"))
+			Expect(project.Properties.Get("Extra")).To(Equal("true"))
+			Expect(project.Imports).To(Equal([]string{
+				filepath.Join(root, "build", "common.props"),
+				filepath.Join(root, "build", "versions.props"),
+				filepath.Join(root, "build", "extra.targets"),
+			}))
+		})
+	})
+
+	context("when there are Directory.Build.props and Directory.Build.targets files", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(root, "Directory.Build.props"), []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <TargetFramework>net6.0</TargetFramework>
+				    <RootProps>true</RootProps>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(root, "src", "Directory.Build.props"), []byte(`
+				<Project>
+				  <Import Project="$([MSBuild]::GetPathOfFileAbove('Directory.Build.props', '$(MSBuildThisFileDirectory)../'))" />
+				  <PropertyGroup>
+				    <TargetFramework>net8.0</TargetFramework>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(root, "Directory.Build.targets"), []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <Targets>$(TargetFramework)</Targets>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(path, []byte(`
+				<Project>
+				  <PropertyGroup>
+				    <InheritedFramework>$(TargetFramework)</InheritedFramework>
+				    <TargetFramework>net9.0</TargetFramework>
+				  </PropertyGroup>
+				</Project>
+			`), 0600)).To(Succeed())
+		})
+
+		it("imports the closest ones around the project", func() {
+			project, err := internal.EvaluateMSBuildProject(path, root, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(project.Properties.Get("RootProps")).To(Equal("true"))
+			Expect(project.Properties.Get("InheritedFramework")).To(Equal("net8.0"))
+			Expect(project.Properties.Get("TargetFramework")).To(Equal("net9.0"))
+			Expect(project.Properties.Get("Targets")).To(Equal("net9.0"))
+		})
+
+		context("when ImportDirectoryBuildProps is false", func() {
+			it("does not import Directory.Build.props", func() {
+				project, err := internal.EvaluateMSBuildProject(path, root, map[string]string{"ImportDirectoryBuildProps": "false"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(project.Properties.Has("RootProps")).To(BeFalse())
+				Expect(project.Properties.Get("InheritedFramework")).To(Equal(""))
+			})
+		})
+
+		context("when the files are outside of the root directory", func() {
+			it("does not import them", func() {
+				project, err := internal.EvaluateMSBuildProject(path, filepath.Join(root, "src", "app"), nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(project.Properties.Has("RootProps")).To(BeFalse())
+				Expect(project.Properties.Has("Targets")).To(BeFalse())
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the project file can not be read", func() {
+			it("errors", func() {
+				_, err := internal.EvaluateMSBuildProject(path, root, nil)
+				Expect(err).To(MatchError(ContainSubstring("failed to read project file")))
+			})
+		})
+
+		context("when the project file can not be parsed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("errors", func() {
+				_, err := internal.EvaluateMSBuildProject(path, root, nil)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse project file")))
+			})
+		})
+
+		context("when an imported file can not be parsed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(root, "Directory.Build.props"), []byte("%%%"), 0600)).To(Succeed())
+				Expect(os.WriteFile(path, []byte("<Project></Project>"), 0600)).To(Succeed())
+			})
+
+			it("errors", func() {
+				_, err := internal.EvaluateMSBuildProject(path, root, nil)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse Directory.Build.props")))
+			})
+		})
+	})
+}
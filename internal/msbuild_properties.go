@@ -0,0 +1,633 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// Properties holds evaluated MSBuild properties. Like in MSBuild, property
+// names are case-insensitive.
+type Properties map[string]string
+
+func (p Properties) Get(name string) string {
+	return p[strings.ToLower(name)]
+}
+
+func (p Properties) Set(name, value string) {
+	p[strings.ToLower(name)] = value
+}
+
+func (p Properties) Has(name string) bool {
+	_, ok := p[strings.ToLower(name)]
+	return ok
+}
+
+func (p Properties) Copy() Properties {
+	properties := Properties{}
+	for name, value := range p {
+		properties[name] = value
+	}
+	return properties
+}
+
+// Expand substitutes the $(Property) references in the given string, including
+// the small set of property functions supported by evaluateFunction. Unknown
+// properties and unsupported functions expand to an empty string.
+func (p Properties) Expand(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$(") {
+			end := closingParen(s, i+1)
+			if end < 0 {
+				builder.WriteString(s[i:])
+				break
+			}
+
+			builder.WriteString(p.evaluateProperty(s[i+2 : end]))
+			i = end + 1
+			continue
+		}
+
+		builder.WriteByte(s[i])
+		i++
+	}
+
+	return builder.String()
+}
+
+var (
+	propertyNameRe   = regexp.MustCompile(`^[A-Za-z_][\w-]*$`)
+	propertyMethodRe = regexp.MustCompile(`^([A-Za-z_][\w-]*)\.(\w+)\((.*)\)$`)
+	staticFunctionRe = regexp.MustCompile(`^\[([\w.]+)\]::(\w+)\((.*)\)$`)
+)
+
+func (p Properties) evaluateProperty(expression string) string {
+	expression = strings.TrimSpace(expression)
+
+	if propertyNameRe.MatchString(expression) {
+		return p.Get(expression)
+	}
+
+	if matches := staticFunctionRe.FindStringSubmatch(expression); matches != nil {
+		return p.evaluateFunction(matches[1], matches[2], p.expandArguments(matches[3]))
+	}
+
+	if matches := propertyMethodRe.FindStringSubmatch(expression); matches != nil {
+		value := p.Get(matches[1])
+		arguments := p.expandArguments(matches[3])
+
+		switch strings.ToLower(matches[2]) {
+		case "trim":
+			return strings.TrimSpace(value)
+		case "tolower", "tolowerinvariant":
+			return strings.ToLower(value)
+		case "toupper", "toupperinvariant":
+			return strings.ToUpper(value)
+		case "replace":
+			if len(arguments) == 2 {
+				return strings.ReplaceAll(value, arguments[0], arguments[1])
+			}
+		}
+	}
+
+	return ""
+}
+
+func (p Properties) evaluateFunction(class, name string, arguments []string) string {
+	argument := func(i int) string {
+		if i < len(arguments) {
+			return arguments[i]
+		}
+		return ""
+	}
+
+	switch strings.ToLower(class + "::" + name) {
+	case "msbuild::getpathoffileabove":
+		startDir := argument(1)
+		if startDir == "" {
+			startDir = p.Get("MSBuildThisFileDirectory")
+		}
+		return findFileAbove(startDir, argument(0))
+
+	case "msbuild::getdirectorynameoffileabove":
+		path := findFileAbove(argument(0), argument(1))
+		if path == "" {
+			return ""
+		}
+		return filepath.Dir(path)
+
+	case "msbuild::isosplatform", "msbuild::isosunixlike":
+		if strings.EqualFold(name, "IsOsUnixLike") {
+			return strconv.FormatBool(runtime.GOOS != "windows")
+		}
+		return strconv.FormatBool(strings.EqualFold(argument(0), runtime.GOOS) ||
+			(runtime.GOOS == "darwin" && strings.EqualFold(argument(0), "OSX")))
+
+	case "msbuild::valueordefault":
+		if argument(0) != "" {
+			return argument(0)
+		}
+		return argument(1)
+
+	case "msbuild::ensuretrailingslash":
+		return ensureTrailingSlash(argument(0))
+
+	case "msbuild::normalizedirectory":
+		return ensureTrailingSlash(filepath.Join(normalizePaths(arguments)...))
+
+	case "msbuild::normalizepath", "system.io.path::combine":
+		return filepath.Join(normalizePaths(arguments)...)
+	}
+
+	return ""
+}
+
+func (p Properties) expandArguments(arguments string) []string {
+	var expanded []string
+	for _, argument := range splitArguments(arguments) {
+		argument = strings.TrimSpace(argument)
+		if len(argument) >= 2 && strings.ContainsRune("'`\"", rune(argument[0])) && argument[len(argument)-1] == argument[0] {
+			argument = argument[1 : len(argument)-1]
+		}
+		expanded = append(expanded, p.Expand(argument))
+	}
+	return expanded
+}
+
+// EvaluateCondition evaluates an MSBuild condition expression. It supports
+// string and numeric comparisons, the 'and', 'or' and '!' operators,
+// parentheses and the Exists and HasTrailingSlash functions. An empty
+// condition is true.
+func (p Properties) EvaluateCondition(condition string) (bool, error) {
+	if strings.TrimSpace(condition) == "" {
+		return true, nil
+	}
+
+	tokens, err := tokenizeCondition(condition)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	parser := conditionParser{tokens: tokens, properties: p}
+	value, err := parser.parseOr()
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	if parser.position != len(tokens) {
+		return false, fmt.Errorf("invalid condition %q: unexpected %q", condition, tokens[parser.position].text)
+	}
+
+	return value.boolean()
+}
+
+type conditionTokenKind int
+
+const (
+	stringToken conditionTokenKind = iota
+	wordToken
+	operatorToken
+)
+
+type conditionToken struct {
+	kind conditionTokenKind
+	text string
+}
+
+func tokenizeCondition(condition string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	for i := 0; i < len(condition); {
+		c := condition[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '\'':
+			end := strings.IndexByte(condition[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, conditionToken{kind: stringToken, text: condition[i+1 : i+1+end]})
+			i += end + 2
+
+		case strings.HasPrefix(condition[i:], "$("):
+			end := closingParen(condition, i+1)
+			if end < 0 {
+				return nil, errors.New("unterminated property")
+			}
+			tokens = append(tokens, conditionToken{kind: stringToken, text: condition[i : end+1]})
+			i = end + 1
+
+		case strings.HasPrefix(condition[i:], "=="), strings.HasPrefix(condition[i:], "!="),
+			strings.HasPrefix(condition[i:], "<="), strings.HasPrefix(condition[i:], ">="):
+			tokens = append(tokens, conditionToken{kind: operatorToken, text: condition[i : i+2]})
+			i += 2
+
+		case strings.ContainsRune("()!<>,", rune(c)):
+			tokens = append(tokens, conditionToken{kind: operatorToken, text: string(c)})
+			i++
+
+		default:
+			start := i
+			for i < len(condition) && !strings.ContainsRune(" \t\r\n'()!<>=,", rune(condition[i])) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected %q", string(c))
+			}
+			tokens = append(tokens, conditionToken{kind: wordToken, text: condition[start:i]})
+		}
+	}
+
+	return tokens, nil
+}
+
+type conditionValue struct {
+	text      string
+	isBoolean bool
+	value     bool
+}
+
+func (v conditionValue) boolean() (bool, error) {
+	if v.isBoolean {
+		return v.value, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(v.text)) {
+	case "true", "on", "yes":
+		return true, nil
+	case "false", "off", "no":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%q is not a boolean", v.text)
+}
+
+func (v conditionValue) String() string {
+	if v.isBoolean {
+		return strconv.FormatBool(v.value)
+	}
+	return v.text
+}
+
+type conditionParser struct {
+	tokens     []conditionToken
+	position   int
+	properties Properties
+}
+
+func (c *conditionParser) peek() (conditionToken, bool) {
+	if c.position < len(c.tokens) {
+		return c.tokens[c.position], true
+	}
+	return conditionToken{}, false
+}
+
+func (c *conditionParser) acceptOperator(operator string) bool {
+	token, ok := c.peek()
+	if ok && token.kind == operatorToken && token.text == operator {
+		c.position++
+		return true
+	}
+	return false
+}
+
+func (c *conditionParser) acceptWord(word string) bool {
+	token, ok := c.peek()
+	if ok && token.kind == wordToken && strings.EqualFold(token.text, word) {
+		c.position++
+		return true
+	}
+	return false
+}
+
+func (c *conditionParser) parseOr() (conditionValue, error) {
+	left, err := c.parseAnd()
+	if err != nil {
+		return conditionValue{}, err
+	}
+
+	for c.acceptWord("or") {
+		right, err := c.parseAnd()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		l, err := left.boolean()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		r, err := right.boolean()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		left = conditionValue{isBoolean: true, value: l || r}
+	}
+
+	return left, nil
+}
+
+func (c *conditionParser) parseAnd() (conditionValue, error) {
+	left, err := c.parseNot()
+	if err != nil {
+		return conditionValue{}, err
+	}
+
+	for c.acceptWord("and") {
+		right, err := c.parseNot()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		l, err := left.boolean()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		r, err := right.boolean()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		left = conditionValue{isBoolean: true, value: l && r}
+	}
+
+	return left, nil
+}
+
+func (c *conditionParser) parseNot() (conditionValue, error) {
+	if c.acceptOperator("!") {
+		value, err := c.parseNot()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		b, err := value.boolean()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		return conditionValue{isBoolean: true, value: !b}, nil
+	}
+
+	return c.parseComparison()
+}
+
+func (c *conditionParser) parseComparison() (conditionValue, error) {
+	left, err := c.parseOperand()
+	if err != nil {
+		return conditionValue{}, err
+	}
+
+	token, ok := c.peek()
+	if !ok || token.kind != operatorToken {
+		return left, nil
+	}
+
+	switch token.text {
+	case "==", "!=", "<", ">", "<=", ">=":
+		c.position++
+	default:
+		return left, nil
+	}
+
+	right, err := c.parseOperand()
+	if err != nil {
+		return conditionValue{}, err
+	}
+
+	result, err := compare(left.String(), right.String(), token.text)
+	if err != nil {
+		return conditionValue{}, err
+	}
+
+	return conditionValue{isBoolean: true, value: result}, nil
+}
+
+func (c *conditionParser) parseOperand() (conditionValue, error) {
+	token, ok := c.peek()
+	if !ok {
+		return conditionValue{}, errors.New("unexpected end of condition")
+	}
+
+	if c.acceptOperator("(") {
+		value, err := c.parseOr()
+		if err != nil {
+			return conditionValue{}, err
+		}
+
+		if !c.acceptOperator(")") {
+			return conditionValue{}, errors.New("missing closing parenthesis")
+		}
+
+		return value, nil
+	}
+
+	switch token.kind {
+	case stringToken:
+		c.position++
+		return conditionValue{text: c.properties.Expand(token.text)}, nil
+
+	case wordToken:
+		c.position++
+		if c.acceptOperator("(") {
+			return c.parseFunction(token.text)
+		}
+		return conditionValue{text: c.properties.Expand(token.text)}, nil
+	}
+
+	return conditionValue{}, fmt.Errorf("unexpected %q", token.text)
+}
+
+func (c *conditionParser) parseFunction(name string) (conditionValue, error) {
+	var arguments []string
+	for !c.acceptOperator(")") {
+		if len(arguments) > 0 && !c.acceptOperator(",") {
+			return conditionValue{}, fmt.Errorf("invalid arguments to %s", name)
+		}
+
+		argument, err := c.parseOperand()
+		if err != nil {
+			return conditionValue{}, err
+		}
+		arguments = append(arguments, argument.String())
+	}
+
+	if len(arguments) != 1 {
+		return conditionValue{}, fmt.Errorf("%s expects a single argument", name)
+	}
+
+	switch strings.ToLower(name) {
+	case "exists":
+		path := strings.TrimSpace(arguments[0])
+		if path == "" {
+			return conditionValue{isBoolean: true}, nil
+		}
+
+		path = filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.properties.Get("MSBuildProjectDirectory"), path)
+		}
+
+		_, err := os.Stat(path)
+		return conditionValue{isBoolean: true, value: err == nil}, nil
+
+	case "hastrailingslash":
+		return conditionValue{isBoolean: true, value: strings.HasSuffix(arguments[0], "/") || strings.HasSuffix(arguments[0], `\`)}, nil
+	}
+
+	return conditionValue{}, fmt.Errorf("unsupported function %s", name)
+}
+
+func compare(left, right, operator string) (bool, error) {
+	leftNumber, leftErr := strconv.ParseFloat(strings.TrimSpace(left), 64)
+	rightNumber, rightErr := strconv.ParseFloat(strings.TrimSpace(right), 64)
+	numeric := leftErr == nil && rightErr == nil
+
+	switch operator {
+	case "==":
+		if numeric {
+			return leftNumber == rightNumber, nil
+		}
+		return strings.EqualFold(left, right), nil
+	case "!=":
+		if numeric {
+			return leftNumber != rightNumber, nil
+		}
+		return !strings.EqualFold(left, right), nil
+	}
+
+	var comparison int
+	switch {
+	case numeric:
+		if leftNumber < rightNumber {
+			comparison = -1
+		} else if leftNumber > rightNumber {
+			comparison = 1
+		}
+	default:
+		leftVersion, err := semver.NewVersion(strings.TrimSpace(left))
+		if err != nil {
+			return false, fmt.Errorf("cannot compare %q and %q", left, right)
+		}
+
+		rightVersion, err := semver.NewVersion(strings.TrimSpace(right))
+		if err != nil {
+			return false, fmt.Errorf("cannot compare %q and %q", left, right)
+		}
+
+		comparison = leftVersion.Compare(rightVersion)
+	}
+
+	switch operator {
+	case "<":
+		return comparison < 0, nil
+	case ">":
+		return comparison > 0, nil
+	case "<=":
+		return comparison <= 0, nil
+	default:
+		return comparison >= 0, nil
+	}
+}
+
+// closingParen returns the index of the parenthesis closing the one at the
+// given index, skipping over quoted strings.
+func closingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '`' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func splitArguments(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	var (
+		arguments []string
+		depth     int
+		quote     byte
+		start     int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '`' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			arguments = append(arguments, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(arguments, s[start:])
+}
+
+func findFileAbove(startDir, file string) string {
+	if startDir == "" || file == "" {
+		return ""
+	}
+
+	for dir := filepath.Clean(startDir); ; dir = filepath.Dir(dir) {
+		path := filepath.Join(dir, file)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+func normalizePaths(paths []string) []string {
+	var normalized []string
+	for _, path := range paths {
+		normalized = append(normalized, filepath.FromSlash(strings.ReplaceAll(path, `\`, "/")))
+	}
+	return normalized
+}
+
+func ensureTrailingSlash(path string) string {
+	if path == "" || strings.HasSuffix(path, "/") || strings.HasSuffix(path, `\`) {
+		return path
+	}
+	return path + string(filepath.Separator)
+}
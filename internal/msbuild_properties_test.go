@@ -0,0 +1,160 @@
+package internal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/dotnet-publish/internal"
+	"github.com/sclevine/spec"
+)
+
+func testProperties(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect     = NewWithT(t).Expect
+		properties internal.Properties
+	)
+
+	it.Before(func() {
+		properties = internal.Properties{}
+		properties.Set("Configuration", "Release")
+		properties.Set("TargetFramework", "net8.0")
+		properties.Set("Version", "8.0")
+	})
+
+	context("Get", func() {
+		it("looks up properties case-insensitively", func() {
+			Expect(properties.Get("configuration")).To(Equal("Release"))
+			Expect(properties.Has("TARGETFRAMEWORK")).To(BeTrue())
+			Expect(properties.Has("Missing")).To(BeFalse())
+		})
+	})
+
+	context("Expand", func() {
+		it("substitutes property references", func() {
+			Expect(properties.Expand("bin/$(Configuration)/$(TargetFramework)/")).To(Equal("bin/Release/net8.0/"))
+		})
+
+		it("expands unknown properties to an empty string", func() {
+			Expect(properties.Expand("a$(Missing)b")).To(Equal("ab"))
+		})
+
+		it("leaves unterminated references as they are", func() {
+			Expect(properties.Expand("a$(Missing")).To(Equal("a$(Missing"))
+		})
+
+		it("supports string methods", func() {
+			properties.Set("Name", " Some.Name ")
+			Expect(properties.Expand("$(Name.Trim())")).To(Equal("Some.Name"))
+			Expect(properties.Expand("$(Configuration.ToLower())")).To(Equal("release"))
+			Expect(properties.Expand("$(TargetFramework.Replace('net', 'NET'))")).To(Equal("NET8.0"))
+		})
+
+		it("supports property functions", func() {
+			Expect(properties.Expand("$([System.IO.Path]::Combine('/some', '$(Configuration)', 'file'))")).To(Equal("/some/Release/file"))
+			Expect(properties.Expand("$([MSBuild]::EnsureTrailingSlash('/some/dir'))")).To(Equal("/some/dir/"))
+			Expect(properties.Expand("$([MSBuild]::NormalizeDirectory('/some', 'dir'))")).To(Equal("/some/dir/"))
+			Expect(properties.Expand("$([MSBuild]::ValueOrDefault('$(Missing)', 'default'))")).To(Equal("default"))
+			Expect(properties.Expand("$([MSBuild]::IsOSPlatform('Linux'))")).To(Equal("true"))
+			Expect(properties.Expand("$([MSBuild]::Unknown())")).To(Equal(""))
+		})
+
+		context("when looking up files in parent directories", func() {
+			var dir string
+
+			it.Before(func() {
+				var err error
+				dir, err = os.MkdirTemp("", "properties")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.MkdirAll(filepath.Join(dir, "src", "app"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "Common.props"), nil, 0600)).To(Succeed())
+
+				properties.Set("MSBuildThisFileDirectory", filepath.Join(dir, "src", "app")+"/")
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(dir)).To(Succeed())
+			})
+
+			it("finds the closest file", func() {
+				Expect(properties.Expand("$([MSBuild]::GetPathOfFileAbove('Common.props'))")).To(Equal(filepath.Join(dir, "Common.props")))
+				Expect(properties.Expand("$([MSBuild]::GetPathOfFileAbove('Missing.props'))")).To(Equal(""))
+				Expect(properties.Expand("$([MSBuild]::GetDirectoryNameOfFileAbove($(MSBuildThisFileDirectory), Common.props))")).To(Equal(dir))
+			})
+		})
+	})
+
+	context("EvaluateCondition", func() {
+		it("evaluates conditions", func() {
+			for condition, expected := range map[string]bool{
+				"":                                    true,
+				"true":                                true,
+				"'$(Configuration)' == 'Release'":     true,
+				"'$(Configuration)' == 'release'":     true,
+				"'$(Configuration)' != 'Release'":     false,
+				"$(Configuration) == Debug":           false,
+				"'$(Missing)' == ''":                  true,
+				"!('$(Missing)' == '')":               false,
+				"'$(Version)' >= '6.0'":               true,
+				"'$(Version)' < '8.0'":                false,
+				"'$(Version)' == '8'":                 true,
+				"'8.0.100' > '8.0.10'":                true,
+				"false or '$(Version)' <= '8.0'":      true,
+				"true and ('a' == 'b' or 'c' == 'c')": true,
+				"true AND false":                      false,
+				"HasTrailingSlash('some/dir/')":       true,
+				"HasTrailingSlash('some/dir')":        false,
+			} {
+				result, err := properties.EvaluateCondition(condition)
+				Expect(err).NotTo(HaveOccurred(), condition)
+				Expect(result).To(Equal(expected), condition)
+			}
+		})
+
+		context("when checking for files", func() {
+			var dir string
+
+			it.Before(func() {
+				var err error
+				dir, err = os.MkdirTemp("", "properties")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(dir, "some-file"), nil, 0600)).To(Succeed())
+
+				properties.Set("MSBuildProjectDirectory", dir)
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(dir)).To(Succeed())
+			})
+
+			it("resolves relative paths against the project directory", func() {
+				result, err := properties.EvaluateCondition("Exists('some-file')")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(BeTrue())
+
+				result, err = properties.EvaluateCondition(`!Exists('$(MSBuildProjectDirectory)\missing-file')`)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(BeTrue())
+			})
+		})
+
+		context("failure cases", func() {
+			it("errors on invalid conditions", func() {
+				for _, condition := range []string{
+					"'unterminated",
+					"('a' == 'a'",
+					"'a' == 'a' 'b'",
+					"'a' and 'b'",
+					"'a' < 'b'",
+					"Unknown('a')",
+				} {
+					_, err := properties.EvaluateCondition(condition)
+					Expect(err).To(MatchError(ContainSubstring("invalid condition")), condition)
+				}
+			})
+		})
+	})
+}
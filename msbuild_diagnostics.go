@@ -0,0 +1,123 @@
+package dotnetpublish
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// maxLoggedDiagnostics is the number of errors listed in the summary of a
+// failed command.
+const maxLoggedDiagnostics = 10
+
+// msbuildErrorRe matches the errors MSBuild writes to its output, such as
+//
+//	/workspace/Program.cs(12,5): error CS1002: ; expected [/workspace/app.csproj]
+//	/workspace/app.csproj : error NU1101: Unable to find package Foo. [/workspace/app.csproj]
+//	MSBUILD : error MSB1009: Project file does not exist.
+var msbuildErrorRe = regexp.MustCompile(`^\s*(?:(.*?)(?:\((\d+)(?:,(\d+))?(?:[,-]\d+)*\))?\s*:\s*)?error\s+([A-Z]+\d+)\s*:\s*(.*?)(?:\s+\[[^\]]*\])?\s*$`)
+
+// msbuildErrorHints are hints for the errors that are most often caused by the
+// configuration of the build rather than by the code of the application.
+var msbuildErrorHints = map[string]string{
+	"NU1101":     "A package could not be found. Check that its source is configured, for example in a NuGet.Config provided with a nugetconfig service binding.",
+	"NU1102":     "A package version could not be found. Check that its source is configured, for example in a NuGet.Config provided with a nugetconfig service binding.",
+	"NU1301":     "A package source could not be reached. Check the sources and credentials of the NuGet.Config, for example of the nugetconfig service binding.",
+	"NU1004":     "The lock file is out of date. Restore the project with --force-evaluate and commit the updated packages.lock.json.",
+	"NU1403":     "A package does not match the hash in the lock file. Restore the project with --force-evaluate and commit the updated packages.lock.json.",
+	"NETSDK1004": "The packages of the project were not restored. The buildpack does not run dotnet restore when BP_DOTNET_PUBLISH_FLAGS contains --no-restore, so remove it or restore the packages with BP_DOTNET_PRE_PUBLISH_COMMAND.",
+	"NETSDK1045": "The .NET SDK is too old for the TargetFramework of the project. Require a newer SDK with the sdk.version of a global.json file.",
+	"NETSDK1047": "The assets file has no target for the runtime identifier or framework being published. Check that dotnet restore and dotnet publish use the same RuntimeIdentifier, and set it with --runtime or BP_DOTNET_RUNTIME_IDENTIFIER rather than with -p:RuntimeIdentifier.",
+	"NETSDK1083": "The runtime identifier is not known to the .NET SDK. Check BP_DOTNET_RUNTIME_IDENTIFIER and the RuntimeIdentifier of the project.",
+	"MSB1009":    "The project file does not exist. Check BP_DOTNET_PROJECT_PATH and BP_DOTNET_PROJECT_NAME.",
+}
+
+// MSBuildDiagnostic is an error reported by MSBuild. File, Line and Column are
+// empty when MSBuild does not report where the error occurred.
+type MSBuildDiagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Code    string
+	Message string
+}
+
+// ParseMSBuildDiagnostics returns the errors found in the output of dotnet.
+// MSBuild repeats its errors at the end of the build, so each error is only
+// returned once.
+func ParseMSBuildDiagnostics(output string) []MSBuildDiagnostic {
+	var diagnostics []MSBuildDiagnostic
+	seen := map[MSBuildDiagnostic]bool{}
+
+	for _, line := range strings.Split(output, "\n") {
+		matches := msbuildErrorRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if matches == nil {
+			continue
+		}
+
+		diagnostic := MSBuildDiagnostic{
+			File:    strings.TrimSpace(matches[1]),
+			Code:    matches[4],
+			Message: matches[5],
+		}
+		if diagnostic.File == "MSBUILD" {
+			diagnostic.File = ""
+		}
+		diagnostic.Line, _ = strconv.Atoi(matches[2])
+		diagnostic.Column, _ = strconv.Atoi(matches[3])
+
+		if !seen[diagnostic] {
+			seen[diagnostic] = true
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
+	return diagnostics
+}
+
+// Hint returns advice on how to fix the error, or an empty string when there
+// is none.
+func (d MSBuildDiagnostic) Hint() string {
+	return msbuildErrorHints[d.Code]
+}
+
+func (d MSBuildDiagnostic) String() string {
+	var location string
+	switch {
+	case d.File != "" && d.Line > 0 && d.Column > 0:
+		location = fmt.Sprintf("%s(%d,%d): ", d.File, d.Line, d.Column)
+	case d.File != "" && d.Line > 0:
+		location = fmt.Sprintf("%s(%d): ", d.File, d.Line)
+	case d.File != "":
+		location = fmt.Sprintf("%s: ", d.File)
+	}
+
+	return fmt.Sprintf("%serror %s: %s", location, d.Code, d.Message)
+}
+
+// logMSBuildDiagnostics prints a summary of the MSBuild errors found in the
+// output of a failed command, along with hints on how to fix them.
+func logMSBuildDiagnostics(logger scribe.Emitter, output string) {
+	diagnostics := ParseMSBuildDiagnostics(output)
+	if len(diagnostics) == 0 {
+		return
+	}
+
+	logger.Break()
+	logger.Subprocess("Found %d error(s):", len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		if i == maxLoggedDiagnostics {
+			logger.Action("... and %d more", len(diagnostics)-maxLoggedDiagnostics)
+			break
+		}
+
+		logger.Action("%s", diagnostic)
+		if hint := diagnostic.Hint(); hint != "" {
+			logger.Action("  Hint: %s", hint)
+		}
+	}
+	logger.Break()
+}
@@ -0,0 +1,56 @@
+package dotnetpublish_test
+
+import (
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMSBuildDiagnostics(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseMSBuildDiagnostics", func() {
+		it("returns the errors found in the output once", func() {
+			diagnostics := dotnetpublish.ParseMSBuildDiagnostics(`  Determining projects to restore...
+/workspace/app.csproj : error NU1101: Unable to find package Some.Package. No packages exist with this id in source(s): nuget.org [/workspace/app.csproj]
+/workspace/Program.cs(12,5): error CS1002: ; expected [/workspace/app.csproj]
+/workspace/Program.cs(3,1): warning CS8321: The local function 'Unused' is declared but never used [/workspace/app.csproj]
+/usr/share/dotnet/sdk/6.0.100/Microsoft.NET.TargetFrameworkInference.targets(141,5): error NETSDK1045: The current .NET SDK does not support targeting .NET 8.0. [/workspace/app.csproj]
+MSBUILD : error MSB1009: Project file does not exist.
+
+Build FAILED.
+
+/workspace/Program.cs(12,5): error CS1002: ; expected [/workspace/app.csproj]
+    0 Warning(s)
+    3 Error(s)
+`)
+
+			Expect(diagnostics).To(Equal([]dotnetpublish.MSBuildDiagnostic{
+				{File: "/workspace/app.csproj", Code: "NU1101", Message: "Unable to find package Some.Package. No packages exist with this id in source(s): nuget.org"},
+				{File: "/workspace/Program.cs", Line: 12, Column: 5, Code: "CS1002", Message: "; expected"},
+				{File: "/usr/share/dotnet/sdk/6.0.100/Microsoft.NET.TargetFrameworkInference.targets", Line: 141, Column: 5, Code: "NETSDK1045", Message: "The current .NET SDK does not support targeting .NET 8.0."},
+				{Code: "MSB1009", Message: "Project file does not exist."},
+			}))
+		})
+	})
+
+	context("String", func() {
+		it("formats the diagnostic like MSBuild does", func() {
+			Expect(dotnetpublish.MSBuildDiagnostic{File: "Program.cs", Line: 12, Column: 5, Code: "CS1002", Message: "; expected"}.String()).To(Equal("Program.cs(12,5): error CS1002: ; expected"))
+			Expect(dotnetpublish.MSBuildDiagnostic{File: "app.csproj", Code: "NU1101", Message: "Unable to find package"}.String()).To(Equal("app.csproj: error NU1101: Unable to find package"))
+			Expect(dotnetpublish.MSBuildDiagnostic{Code: "MSB1009", Message: "Project file does not exist."}.String()).To(Equal("error MSB1009: Project file does not exist."))
+		})
+	})
+
+	context("Hint", func() {
+		it("returns hints for errors caused by the build configuration", func() {
+			Expect(dotnetpublish.MSBuildDiagnostic{Code: "NU1101"}.Hint()).To(ContainSubstring("nugetconfig service binding"))
+			Expect(dotnetpublish.MSBuildDiagnostic{Code: "NETSDK1045"}.Hint()).To(ContainSubstring("SDK is too old"))
+			Expect(dotnetpublish.MSBuildDiagnostic{Code: "NETSDK1047"}.Hint()).To(ContainSubstring("same RuntimeIdentifier"))
+			Expect(dotnetpublish.MSBuildDiagnostic{Code: "CS1002"}.Hint()).To(BeEmpty())
+		})
+	})
+}
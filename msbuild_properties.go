@@ -0,0 +1,146 @@
+package dotnetpublish
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// msbuildPropertyEscaper escapes the characters that MSBuild would otherwise
+// interpret in the value of a property set on the command line.
+var msbuildPropertyEscaper = strings.NewReplacer("%", "%25", `"`, "%22")
+
+// MSBuildProperties are MSBuild properties set by the configuration of the
+// buildpack rather than by flags, keyed by their names.
+type MSBuildProperties map[string]string
+
+// LoadMSBuildProperties returns the properties of the value of
+// BP_DOTNET_MSBUILD_PROPERTIES and of the file at the given path, when it is
+// not empty. A property may only be set in one of them.
+func LoadMSBuildProperties(value, path string) (MSBuildProperties, error) {
+	properties, err := ParseMSBuildProperties(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse BP_DOTNET_MSBUILD_PROPERTIES: %w", err)
+	}
+
+	if path == "" {
+		return properties, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MSBuild properties file: %w", err)
+	}
+
+	fileProperties, err := ParseMSBuildProperties(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MSBuild properties file %s: %w", path, err)
+	}
+
+	for name, value := range fileProperties {
+		err = properties.set(name, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse MSBuild properties file %s: %w", path, err)
+		}
+	}
+
+	return properties, nil
+}
+
+// ParseMSBuildProperties parses MSBuild properties given either as a JSON
+// object, whose values are strings, numbers or booleans, or as one Name=Value
+// property per line, whose name and value are trimmed. Empty lines and lines
+// starting with # are ignored. Like in MSBuild, property names are
+// case-insensitive, so a property may only be set once.
+func ParseMSBuildProperties(content string) (MSBuildProperties, error) {
+	properties := MSBuildProperties{}
+
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		var values map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+		err := decoder.Decode(&values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %w", err)
+		}
+
+		for name, value := range values {
+			var rendered string
+			switch value := value.(type) {
+			case string:
+				rendered = value
+			case json.Number:
+				rendered = value.String()
+			case bool:
+				rendered = fmt.Sprint(value)
+			default:
+				return nil, fmt.Errorf("invalid MSBuild property %q: value must be a string, number or boolean", name)
+			}
+
+			err = properties.set(name, rendered)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return properties, nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid MSBuild property %q: must be of the form Name=Value", line)
+		}
+
+		err := properties.set(strings.TrimSpace(name), strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return properties, nil
+}
+
+// Flags returns the -p flags that set the properties, sorted by name. Values
+// are passed to MSBuild literally: % and " are escaped, and values containing
+// the ; and , separators of MSBuild are quoted.
+func (p MSBuildProperties) Flags() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]string, 0, len(names))
+	for _, name := range names {
+		value := msbuildPropertyEscaper.Replace(p[name])
+		if strings.ContainsAny(value, ";,") {
+			value = fmt.Sprintf(`"%s"`, value)
+		}
+		flags = append(flags, fmt.Sprintf("-p:%s=%s", name, value))
+	}
+
+	return flags
+}
+
+func (p MSBuildProperties) set(name, value string) error {
+	if !isPropertyName(name) {
+		return fmt.Errorf("invalid MSBuild property %q: name must start with a letter or _ and only contain letters, digits, _ and -", name)
+	}
+
+	for existing := range p {
+		if strings.EqualFold(existing, name) {
+			return fmt.Errorf("MSBuild property %q is set more than once", name)
+		}
+	}
+
+	p[name] = value
+	return nil
+}
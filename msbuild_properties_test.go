@@ -0,0 +1,132 @@
+package dotnetpublish_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMSBuildProperties(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseMSBuildProperties", func() {
+		it("parses one property per line", func() {
+			properties, err := dotnetpublish.ParseMSBuildProperties(`
+# Versioning
+Version=1.2.3
+DefineConstants = A;B
+InformationalVersion=1.2.3 (commit abc)
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(properties).To(Equal(dotnetpublish.MSBuildProperties{
+				"Version":              "1.2.3",
+				"DefineConstants":      "A;B",
+				"InformationalVersion": "1.2.3 (commit abc)",
+			}))
+		})
+
+		it("parses a JSON object of strings, numbers and booleans", func() {
+			properties, err := dotnetpublish.ParseMSBuildProperties(`{"Version": "1.2.3", "WarningLevel": 4, "Deterministic": true}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(properties).To(Equal(dotnetpublish.MSBuildProperties{
+				"Version":       "1.2.3",
+				"WarningLevel":  "4",
+				"Deterministic": "true",
+			}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error for a line that does not set a property", func() {
+				_, err := dotnetpublish.ParseMSBuildProperties("Version")
+				Expect(err).To(MatchError(`invalid MSBuild property "Version": must be of the form Name=Value`))
+			})
+
+			it("returns an error for an invalid property name", func() {
+				_, err := dotnetpublish.ParseMSBuildProperties("1Version=1.2.3")
+				Expect(err).To(MatchError(ContainSubstring(`invalid MSBuild property "1Version"`)))
+			})
+
+			it("returns an error for a property that is set more than once", func() {
+				_, err := dotnetpublish.ParseMSBuildProperties("Version=1.2.3\nversion=1.2.4")
+				Expect(err).To(MatchError(`MSBuild property "version" is set more than once`))
+			})
+
+			it("returns an error for a JSON value that is not a string, number or boolean", func() {
+				_, err := dotnetpublish.ParseMSBuildProperties(`{"Version": ["1.2.3"]}`)
+				Expect(err).To(MatchError(`invalid MSBuild property "Version": value must be a string, number or boolean`))
+			})
+
+			it("returns an error for invalid JSON", func() {
+				_, err := dotnetpublish.ParseMSBuildProperties(`{"Version": }`)
+				Expect(err).To(MatchError(ContainSubstring("invalid JSON object")))
+			})
+		})
+	})
+
+	context("Flags", func() {
+		it("renders the properties sorted by name and escaped", func() {
+			Expect(dotnetpublish.MSBuildProperties{
+				"Version":         "1.2.3",
+				"DefineConstants": "A;B",
+				"Authors":         "Jane, John",
+				"Description":     `50% "faster"`,
+				"Empty":           "",
+			}.Flags()).To(Equal([]string{
+				`-p:Authors="Jane, John"`,
+				`-p:DefineConstants="A;B"`,
+				`-p:Description=50%25 %22faster%22`,
+				`-p:Empty=`,
+				`-p:Version=1.2.3`,
+			}))
+		})
+
+		it("renders properties that the flag parser reads back", func() {
+			publishFlags, err := dotnetpublish.ParsePublishFlags(dotnetpublish.MSBuildProperties{"DefineConstants": "A;B=C"}.Flags())
+			Expect(err).NotTo(HaveOccurred())
+
+			property, ok := publishFlags.Property("DefineConstants")
+			Expect(ok).To(BeTrue())
+			Expect(property.Value).To(Equal("A;B=C"))
+		})
+	})
+
+	context("LoadMSBuildProperties", func() {
+		var path string
+
+		it.Before(func() {
+			path = filepath.Join(t.TempDir(), "msbuild.properties")
+			Expect(os.WriteFile(path, []byte(`{"Deterministic": true}`), 0600)).To(Succeed())
+		})
+
+		it("combines the properties of the value and of the file", func() {
+			properties, err := dotnetpublish.LoadMSBuildProperties("Version=1.2.3", path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(properties).To(Equal(dotnetpublish.MSBuildProperties{
+				"Version":       "1.2.3",
+				"Deterministic": "true",
+			}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error when the value cannot be parsed", func() {
+				_, err := dotnetpublish.LoadMSBuildProperties("Version", "")
+				Expect(err).To(MatchError(ContainSubstring("failed to parse BP_DOTNET_MSBUILD_PROPERTIES")))
+			})
+
+			it("returns an error when the file cannot be read", func() {
+				_, err := dotnetpublish.LoadMSBuildProperties("", filepath.Join(t.TempDir(), "missing"))
+				Expect(err).To(MatchError(ContainSubstring("failed to read MSBuild properties file")))
+			})
+
+			it("returns an error when the value and the file set the same property", func() {
+				_, err := dotnetpublish.LoadMSBuildProperties("deterministic=false", path)
+				Expect(err).To(MatchError(ContainSubstring(`MSBuild property "Deterministic" is set more than once`)))
+			})
+		})
+	})
+}
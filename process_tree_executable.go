@@ -0,0 +1,88 @@
+package dotnetpublish
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// ProcessTreeExecutable is an executable on the $PATH that runs in its own
+// process group, so that it can be stopped together with the processes it
+// starts, such as MSBuild nodes and compiler servers.
+type ProcessTreeExecutable struct {
+	name        string
+	gracePeriod time.Duration
+}
+
+// NewProcessTreeExecutable returns an executable with the given name. When
+// its context is done, the process tree is sent SIGTERM and, if it is still
+// running after the grace period, SIGKILL.
+func NewProcessTreeExecutable(name string, gracePeriod time.Duration) ProcessTreeExecutable {
+	return ProcessTreeExecutable{
+		name:        name,
+		gracePeriod: gracePeriod,
+	}
+}
+
+// Execute invokes the executable with a set of Execution arguments. Like
+// pexec.Executable, it looks the executable up on the PATH of the execution
+// environment when one is set.
+func (e ProcessTreeExecutable) Execute(ctx context.Context, execution pexec.Execution) error {
+	executable, err := e.lookPath(execution.Env)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, executable, execution.Args...)
+	cmd.Dir = execution.Dir
+	if len(execution.Env) > 0 {
+		cmd.Env = execution.Env
+	}
+	cmd.Stdout = execution.Stdout
+	cmd.Stderr = execution.Stderr
+	cmd.Stdin = execution.Stdin
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = e.gracePeriod
+
+	err = cmd.Run()
+	if ctx.Err() != nil && cmd.Process != nil {
+		// Processes of the tree that outlived the grace period, or that
+		// ignored SIGTERM, are killed.
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (e ProcessTreeExecutable) lookPath(env []string) (string, error) {
+	if strings.Contains(e.name, string(os.PathSeparator)) {
+		return exec.LookPath(e.name)
+	}
+
+	for _, variable := range env {
+		path, found := strings.CutPrefix(variable, "PATH=")
+		if !found || path == "" {
+			continue
+		}
+
+		for _, dir := range strings.Split(path, string(os.PathListSeparator)) {
+			executable, err := exec.LookPath(filepath.Join(dir, e.name))
+			if err == nil {
+				return executable, nil
+			}
+		}
+	}
+
+	return exec.LookPath(e.name)
+}
@@ -0,0 +1,84 @@
+package dotnetpublish_test
+
+import (
+	"bytes"
+	gocontext "context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProcessTreeExecutable(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect       = NewWithT(t).Expect
+		Consistently = NewWithT(t).Consistently
+
+		workingDir string
+		executable dotnetpublish.ProcessTreeExecutable
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		executable = dotnetpublish.NewProcessTreeExecutable("sh", time.Second)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("executes the command", func() {
+		buffer := bytes.NewBuffer(nil)
+		err := executable.Execute(gocontext.Background(), pexec.Execution{
+			Args:   []string{"-c", `echo "$SOME_VARIABLE in $(pwd)"; echo error >&2`},
+			Dir:    workingDir,
+			Env:    append(os.Environ(), "SOME_VARIABLE=some-value"),
+			Stdout: buffer,
+			Stderr: buffer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		dir, err := filepath.EvalSymlinks(workingDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buffer.String()).To(ContainSubstring("some-value in " + dir))
+		Expect(buffer.String()).To(ContainSubstring("error"))
+	})
+
+	context("when the context is done", func() {
+		it("stops the process tree", func() {
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			marker := filepath.Join(workingDir, "marker")
+			start := time.Now()
+			err := executable.Execute(ctx, pexec.Execution{
+				Args: []string{"-c", `(sleep 2; touch "$0") & wait`, marker},
+			})
+			Expect(err).To(MatchError(gocontext.DeadlineExceeded))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+			Consistently(func() bool {
+				_, err := os.Stat(marker)
+				return err == nil
+			}, 2500*time.Millisecond, 250*time.Millisecond).Should(BeFalse())
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the executable cannot be found", func() {
+			it("returns an error", func() {
+				err := dotnetpublish.NewProcessTreeExecutable("no-such-executable", time.Second).Execute(gocontext.Background(), pexec.Execution{})
+				Expect(err).To(MatchError(ContainSubstring("executable file not found")))
+			})
+		})
+	})
+}
@@ -0,0 +1,313 @@
+package dotnetpublish
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// ProjectModel is the evaluated view of a project file that is shared by
+// Detect and Build.
+type ProjectModel struct {
+	Path                    string
+	SDK                     string
+	OutputType              string
+	RuntimeFrameworkVersion string
+	TargetFramework         string
+	TargetFrameworks        []string
+	InvariantGlobalization  bool
+	NodeVersion             string
+	NodeVersionSource       string
+	Properties              map[string]string
+	Items                   []ProjectItem
+	Targets                 []ProjectTarget
+	ProjectReferences       []string
+	PackageReferences       []PackageReference
+	PublishProfile          PublishProfile
+}
+
+// PublishProfile holds the properties set by a publish profile (.pubxml) of a
+// project. Its Name is empty when no profile is used.
+type PublishProfile struct {
+	Name       string
+	Path       string
+	Properties map[string]string
+}
+
+type ProjectItem struct {
+	Type     string
+	Include  string
+	Metadata map[string]string
+}
+
+type ProjectTarget struct {
+	Name  string
+	Execs []ProjectExec
+}
+
+type ProjectExec struct {
+	Command          string
+	WorkingDirectory string
+}
+
+type PackageReference struct {
+	Name    string
+	Version string
+}
+
+// Property returns the value of the given property. Like in MSBuild, property
+// names are case-insensitive.
+func (m ProjectModel) Property(name string) string {
+	if value, ok := m.Properties[name]; ok {
+		return value
+	}
+
+	for key, value := range m.Properties {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Property returns the value of the given property of the publish profile.
+func (p PublishProfile) Property(name string) string {
+	return p.Properties[strings.ToLower(name)]
+}
+
+// PublishProperty returns the value of the given property when publishing the
+// project and where it is set. The publish profile is imported after the
+// project file, so its properties take precedence.
+func (m ProjectModel) PublishProperty(name string) (string, string) {
+	if value := m.PublishProfile.Property(name); value != "" {
+		return value, fmt.Sprintf("the publish profile '%s'", m.PublishProfile.Name)
+	}
+
+	return m.Property(name), "the project file"
+}
+
+// IsExecutable reports whether the project builds an application rather than
+// a library.
+func (m ProjectModel) IsExecutable() bool {
+	switch strings.ToLower(m.OutputType) {
+	case "exe", "winexe":
+		return true
+	}
+	return false
+}
+
+// IsTestProject reports whether the project is a test project: it sets
+// IsTestProject or references the Microsoft.NET.Test.Sdk package, whose props
+// set IsTestProject when the package is restored.
+func (m ProjectModel) IsTestProject() bool {
+	if strings.EqualFold(m.Property("IsTestProject"), "true") {
+		return true
+	}
+
+	for _, reference := range m.PackageReferences {
+		if strings.EqualFold(reference.Name, "Microsoft.NET.Test.Sdk") {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplicationType classifies the project as a web, worker, razor, console or
+// library application from its SDK, FrameworkReference items and OutputType.
+func (m ProjectModel) ApplicationType() string {
+	switch {
+	case m.SDK == "Microsoft.NET.Sdk.Web":
+		return "web"
+	case m.SDK == "Microsoft.NET.Sdk.Razor":
+		return "razor"
+	case m.IsExecutable() && m.hasFrameworkReference("Microsoft.AspNetCore.App"):
+		return "web"
+	case m.SDK == "Microsoft.NET.Sdk.Worker":
+		return "worker"
+	case m.IsExecutable():
+		return "console"
+	default:
+		return "library"
+	}
+}
+
+// RequiresASPNETCore reports whether the project runs on the ASP.NET Core
+// shared framework rather than only on the .NET runtime.
+func (m ProjectModel) RequiresASPNETCore() bool {
+	switch m.SDK {
+	case "Microsoft.NET.Sdk.Web", "Microsoft.NET.Sdk.Razor":
+		return true
+	}
+	return m.hasFrameworkReference("Microsoft.AspNetCore.App")
+}
+
+func (m ProjectModel) hasFrameworkReference(name string) bool {
+	for _, item := range m.Items {
+		if item.Type == "FrameworkReference" && strings.EqualFold(item.Include, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RuntimeVersion returns the version of the .NET runtime the project targets,
+// either from its RuntimeFrameworkVersion or from its target framework.
+func (m ProjectModel) RuntimeVersion(framework string) (string, error) {
+	found := m.RuntimeFrameworkVersion != "" || targetFrameworkVersion(m.TargetFramework) != ""
+	for _, targetFramework := range m.TargetFrameworks {
+		found = found || targetFrameworkVersion(targetFramework) != ""
+	}
+
+	if !found {
+		return "", errors.New("failed to find version in project file: missing or invalid TargetFramework property")
+	}
+
+	if m.RuntimeFrameworkVersion != "" && framework == "" {
+		return m.RuntimeFrameworkVersion, nil
+	}
+
+	targetFramework, err := m.selectTargetFramework(framework)
+	if err != nil {
+		return "", err
+	}
+
+	if m.RuntimeFrameworkVersion != "" {
+		return m.RuntimeFrameworkVersion, nil
+	}
+
+	version := targetFrameworkVersion(targetFramework)
+	if version == "" {
+		return "", fmt.Errorf("failed to find version in project file: target framework %q is not supported", targetFramework)
+	}
+
+	return version, nil
+}
+
+// PublishFramework returns the target framework to publish. It returns an
+// empty string for projects that target a single framework unless a framework
+// is requested, in which case it is validated against the project.
+func (m ProjectModel) PublishFramework(framework string) (string, error) {
+	if len(m.TargetFrameworks) == 0 && framework == "" {
+		return "", nil
+	}
+
+	return m.selectTargetFramework(framework)
+}
+
+// selectTargetFramework returns the requested framework if the project targets
+// it. Otherwise it returns the single target framework of the project, or the
+// highest supported one when the project targets several frameworks.
+func (m ProjectModel) selectTargetFramework(framework string) (string, error) {
+	candidates := m.TargetFrameworks
+	if len(candidates) == 0 && targetFrameworkVersion(m.TargetFramework) != "" {
+		candidates = []string{m.TargetFramework}
+	}
+
+	if framework != "" {
+		for _, candidate := range candidates {
+			if strings.EqualFold(candidate, framework) {
+				return candidate, nil
+			}
+		}
+
+		return "", fmt.Errorf("failed to select target framework %q: project targets %s", framework, strings.Join(candidates, ", "))
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	var (
+		selected string
+		highest  *semver.Version
+	)
+	for _, candidate := range candidates {
+		// Platform specific frameworks such as net8.0-windows cannot be
+		// published on Linux.
+		if strings.Contains(candidate, "-") {
+			continue
+		}
+
+		version, err := semver.NewVersion(targetFrameworkVersion(candidate))
+		if err != nil {
+			continue
+		}
+
+		if highest == nil || version.GreaterThan(highest) {
+			selected, highest = candidate, version
+		}
+	}
+
+	if selected == "" {
+		return "", fmt.Errorf("failed to select target framework: none of %s is supported", strings.Join(candidates, ", "))
+	}
+
+	return selected, nil
+}
+
+// This regular expression matches on 'net<x>.<y>',
+// 'net<x>.<y>-<platform>' & 'netcoreapp<x>.<y>'
+var targetFrameworkRe = regexp.MustCompile(`net(?:coreapp)?(?:(\d+\.\d)(?:\-?\w+)?)$`)
+
+func targetFrameworkVersion(targetFramework string) string {
+	matches := targetFrameworkRe.FindStringSubmatch(strings.TrimSpace(targetFramework))
+	if len(matches) == 2 {
+		return fmt.Sprintf("%s.0", matches[1])
+	}
+
+	return ""
+}
+
+// nodePrograms are node and the JavaScript package managers, which all run on
+// node.
+var nodePrograms = []string{"node", "npm", "npx", "yarn", "pnpm", "pnpx"}
+
+// NodeIsRequired reports whether one of the targets of the project runs node
+// or one of the JavaScript package managers.
+func (m ProjectModel) NodeIsRequired() bool {
+	return m.runsProgram(nodePrograms...)
+}
+
+// NPMIsRequired reports whether one of the targets of the project runs npm or
+// npx.
+func (m ProjectModel) NPMIsRequired() bool {
+	return m.runsProgram("npm", "npx")
+}
+
+// YarnIsRequired reports whether one of the targets of the project runs yarn.
+func (m ProjectModel) YarnIsRequired() bool {
+	return m.runsProgram("yarn")
+}
+
+// PNPMIsRequired reports whether one of the targets of the project runs pnpm.
+func (m ProjectModel) PNPMIsRequired() bool {
+	return m.runsProgram("pnpm", "pnpx")
+}
+
+func (m ProjectModel) runsProgram(names ...string) bool {
+	for _, target := range m.Targets {
+		for _, exec := range target.Execs {
+			if exec.runsProgram(names...) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (e ProjectExec) runsProgram(names ...string) bool {
+	for _, program := range execCommandPrograms(e.Command) {
+		for _, name := range names {
+			if program == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,202 @@
+package dotnetpublish_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+)
+
+func testProjectModel(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Property", func() {
+		it("looks up properties case-insensitively", func() {
+			project := dotnetpublish.ProjectModel{
+				Properties: map[string]string{"invariantglobalization": "true"},
+			}
+
+			Expect(project.Property("InvariantGlobalization")).To(Equal("true"))
+			Expect(project.Property("invariantglobalization")).To(Equal("true"))
+			Expect(project.Property("Missing")).To(Equal(""))
+		})
+	})
+
+	context("PublishProperty", func() {
+		it("prefers the publish profile over the project file", func() {
+			project := dotnetpublish.ProjectModel{
+				Properties: map[string]string{"runtimeidentifier": "linux-x64", "selfcontained": "false"},
+				PublishProfile: dotnetpublish.PublishProfile{
+					Name:       "FolderProfile",
+					Properties: map[string]string{"runtimeidentifier": "linux-musl-x64"},
+				},
+			}
+
+			value, source := project.PublishProperty("RuntimeIdentifier")
+			Expect(value).To(Equal("linux-musl-x64"))
+			Expect(source).To(Equal("the publish profile 'FolderProfile'"))
+
+			value, source = project.PublishProperty("SelfContained")
+			Expect(value).To(Equal("false"))
+			Expect(source).To(Equal("the project file"))
+		})
+	})
+
+	context("IsExecutable", func() {
+		it("checks the OutputType", func() {
+			Expect(dotnetpublish.ProjectModel{OutputType: "Exe"}.IsExecutable()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{OutputType: "winexe"}.IsExecutable()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{OutputType: "Library"}.IsExecutable()).To(BeFalse())
+		})
+	})
+
+	context("IsTestProject", func() {
+		it("checks IsTestProject and the Microsoft.NET.Test.Sdk reference", func() {
+			Expect(dotnetpublish.ProjectModel{Properties: map[string]string{"istestproject": "True"}}.IsTestProject()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{PackageReferences: []dotnetpublish.PackageReference{{Name: "microsoft.net.test.sdk"}}}.IsTestProject()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{Properties: map[string]string{"istestproject": "false"}}.IsTestProject()).To(BeFalse())
+		})
+	})
+
+	context("ApplicationType", func() {
+		it("classifies the project", func() {
+			aspNetCore := []dotnetpublish.ProjectItem{{Type: "FrameworkReference", Include: "Microsoft.AspNetCore.App"}}
+
+			for expected, project := range map[string]dotnetpublish.ProjectModel{
+				"web":     {SDK: "Microsoft.NET.Sdk.Web", OutputType: "Exe"},
+				"razor":   {SDK: "Microsoft.NET.Sdk.Razor", OutputType: "Library"},
+				"worker":  {SDK: "Microsoft.NET.Sdk.Worker", OutputType: "Exe"},
+				"console": {SDK: "Microsoft.NET.Sdk", OutputType: "Exe"},
+				"library": {SDK: "Microsoft.NET.Sdk", OutputType: "Library", Items: aspNetCore},
+			} {
+				Expect(project.ApplicationType()).To(Equal(expected))
+			}
+
+			Expect(dotnetpublish.ProjectModel{SDK: "Microsoft.NET.Sdk.Worker", OutputType: "Exe", Items: aspNetCore}.ApplicationType()).To(Equal("web"))
+		})
+	})
+
+	context("RequiresASPNETCore", func() {
+		it("checks the SDK and framework references", func() {
+			Expect(dotnetpublish.ProjectModel{SDK: "Microsoft.NET.Sdk.Web"}.RequiresASPNETCore()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{SDK: "Microsoft.NET.Sdk.Razor"}.RequiresASPNETCore()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{
+				SDK:   "Microsoft.NET.Sdk",
+				Items: []dotnetpublish.ProjectItem{{Type: "FrameworkReference", Include: "microsoft.aspnetcore.app"}},
+			}.RequiresASPNETCore()).To(BeTrue())
+			Expect(dotnetpublish.ProjectModel{SDK: "Microsoft.NET.Sdk.Worker"}.RequiresASPNETCore()).To(BeFalse())
+		})
+	})
+
+	context("RuntimeVersion", func() {
+		it("prefers RuntimeFrameworkVersion", func() {
+			version, err := dotnetpublish.ProjectModel{
+				RuntimeFrameworkVersion: "6.0.5",
+				TargetFramework:         "net6.0",
+			}.RuntimeVersion("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("6.0.5"))
+		})
+
+		it("ignores TargetFramework when TargetFrameworks is set", func() {
+			version, err := dotnetpublish.ProjectModel{
+				TargetFramework:  "net6.0",
+				TargetFrameworks: []string{"net7.0", "net8.0"},
+			}.RuntimeVersion("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("8.0.0"))
+		})
+
+		context("failure cases", func() {
+			context("when no supported framework is targeted", func() {
+				it("errors", func() {
+					_, err := dotnetpublish.ProjectModel{TargetFramework: "netstandard2.0"}.RuntimeVersion("")
+					Expect(err).To(MatchError("failed to find version in project file: missing or invalid TargetFramework property"))
+				})
+			})
+		})
+	})
+
+	context("PublishFramework", func() {
+		it("returns an empty string for a single target framework", func() {
+			framework, err := dotnetpublish.ProjectModel{TargetFramework: "net8.0"}.PublishFramework("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(framework).To(Equal(""))
+		})
+
+		it("returns the highest framework of a multi-targeted project", func() {
+			framework, err := dotnetpublish.ProjectModel{TargetFrameworks: []string{"net8.0", "net6.0"}}.PublishFramework("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(framework).To(Equal("net8.0"))
+		})
+	})
+
+	context("NodeIsRequired", func() {
+		it("checks whether a target runs node or npm", func() {
+			project := dotnetpublish.ProjectModel{
+				Targets: []dotnetpublish.ProjectTarget{
+					{Name: "first-target", Execs: []dotnetpublish.ProjectExec{{Command: "echo hello"}}},
+					{Name: "second-target", Execs: []dotnetpublish.ProjectExec{{Command: "node --version"}}},
+				},
+			}
+			Expect(project.NodeIsRequired()).To(BeTrue())
+
+			project.Targets[1].Execs[0].Command = "npm install"
+			Expect(project.NodeIsRequired()).To(BeTrue())
+
+			project.Targets[1].Execs[0].Command = "echo goodbye"
+			Expect(project.NodeIsRequired()).To(BeFalse())
+		})
+	})
+
+	context("when targets run JavaScript tooling", func() {
+		var project dotnetpublish.ProjectModel
+
+		it.Before(func() {
+			project = dotnetpublish.ProjectModel{
+				Targets: []dotnetpublish.ProjectTarget{
+					{Name: "first-target", Execs: []dotnetpublish.ProjectExec{{Command: "echo npm"}}},
+				},
+			}
+		})
+
+		it("finds the programs anywhere in the commands", func() {
+			for command, expected := range map[string][]bool{
+				"yarn install":                          {true, false, true, false},
+				"pnpm build":                            {true, false, false, true},
+				"npx ng build":                          {true, true, false, false},
+				"/usr/bin/npm run build":                {true, true, false, false},
+				"cd ClientApp && npm ci":                {true, true, false, false},
+				`cd "Client App"; NODE_ENV=prod node x`: {true, false, false, false},
+				"npm.cmd install":                       {true, true, false, false},
+				"dotnet tool restore || yarn run build": {true, false, true, false},
+				"echo 'npm install' | tee npm.log":      {false, false, false, false},
+				"dotnet run --project tools\npm-helper": {false, false, false, false},
+			} {
+				project.Targets[0].Execs[0].Command = command
+				Expect([]bool{
+					project.NodeIsRequired(),
+					project.NPMIsRequired(),
+					project.YarnIsRequired(),
+					project.PNPMIsRequired(),
+				}).To(Equal(expected), command)
+			}
+		})
+	})
+
+	context("NPMIsRequired", func() {
+		it("checks whether a target runs npm", func() {
+			project := dotnetpublish.ProjectModel{
+				Targets: []dotnetpublish.ProjectTarget{
+					{Name: "first-target", Execs: []dotnetpublish.ProjectExec{{Command: "echo hello"}}},
+					{Name: "second-target", Execs: []dotnetpublish.ProjectExec{{Command: "npm install"}}},
+				},
+			}
+			Expect(project.NPMIsRequired()).To(BeTrue())
+
+			project.Targets[1].Execs[0].Command = "node --version"
+			Expect(project.NPMIsRequired()).To(BeFalse())
+		})
+	})
+}
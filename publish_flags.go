@@ -0,0 +1,550 @@
+package dotnetpublish
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+type flagValueKind int
+
+const (
+	flagNoValue flagValueKind = iota
+	flagRequiredValue
+	flagOptionalBoolValue
+	flagOptionalInlineValue
+)
+
+// flagRestoreKind describes how an option of dotnet publish is passed on to
+// dotnet restore: not at all, as is, or as the MSBuild property it sets.
+type flagRestoreKind int
+
+const (
+	flagNotRestored flagRestoreKind = iota
+	flagRestoredAsIs
+	flagRestoredAsProperty
+)
+
+// publishFlagDefinition describes a dotnet publish option. Options that set
+// an MSBuild property are considered equivalent to setting that property
+// directly with -p. Options without a value can imply one, like
+// --no-self-contained does for --self-contained. Definitions are looked up in
+// order, so the first definition of a name is the one that matches it.
+type publishFlagDefinition struct {
+	name     string
+	aliases  []string
+	value    flagValueKind
+	implied  string
+	property string
+	restore  flagRestoreKind
+}
+
+var publishFlagDefinitions = []publishFlagDefinition{
+	{name: "--configuration", aliases: []string{"-c"}, value: flagRequiredValue, property: "Configuration", restore: flagRestoredAsProperty},
+	{name: "--runtime", aliases: []string{"-r"}, value: flagRequiredValue, property: "RuntimeIdentifier", restore: flagRestoredAsIs},
+	{name: "--arch", aliases: []string{"-a"}, value: flagRequiredValue, restore: flagRestoredAsIs},
+	{name: "--os", value: flagRequiredValue, restore: flagRestoredAsIs},
+	{name: "--use-current-runtime", aliases: []string{"--ucr"}, value: flagOptionalBoolValue, restore: flagRestoredAsIs},
+	{name: "--self-contained", aliases: []string{"--sc"}, value: flagOptionalBoolValue, property: "SelfContained", restore: flagRestoredAsProperty},
+	{name: "--self-contained", aliases: []string{"--no-self-contained"}, value: flagNoValue, implied: "false", property: "SelfContained", restore: flagRestoredAsProperty},
+	{name: "--output", aliases: []string{"-o"}, value: flagRequiredValue, property: "PublishDir"},
+	{name: "--framework", aliases: []string{"-f"}, value: flagRequiredValue, property: "TargetFramework"},
+	{name: "--verbosity", aliases: []string{"-v", "-verbosity", "/v", "/verbosity"}, value: flagRequiredValue, restore: flagRestoredAsIs},
+	{name: "--version-suffix", value: flagRequiredValue, property: "VersionSuffix"},
+	{name: "--manifest", value: flagRequiredValue},
+	{name: "--source", value: flagRequiredValue, restore: flagRestoredAsIs},
+	{name: "--no-restore", value: flagNoValue},
+	{name: "--no-build", value: flagNoValue},
+	{name: "--no-dependencies", value: flagNoValue, restore: flagRestoredAsIs},
+	{name: "--nologo", value: flagNoValue},
+	{name: "--force", value: flagNoValue, restore: flagRestoredAsIs},
+	{name: "--interactive", value: flagOptionalBoolValue, restore: flagRestoredAsIs},
+	{name: "--disable-build-servers", value: flagNoValue},
+	{name: "-clp", aliases: []string{"-consoleLoggerParameters", "/clp", "/consoleLoggerParameters"}, value: flagOptionalInlineValue, restore: flagRestoredAsIs},
+	{name: "-bl", aliases: []string{"-binaryLogger", "/bl", "/binaryLogger"}, value: flagOptionalInlineValue, implied: "msbuild.binlog"},
+}
+
+var propertyFlagAliases = []string{"--property", "-p", "-property", "/p", "/property"}
+
+// PublishFlag is a flag of the dotnet publish command line. Token is the flag
+// as it was given, including its value.
+type PublishFlag struct {
+	Name  string
+	Value string
+	Token string
+}
+
+// PublishFlags is the parsed form of the flags given to dotnet publish. It
+// understands the long, short and MSBuild forms of the options that the
+// buildpack sets by default, as well as the MSBuild properties they are
+// equivalent to.
+type PublishFlags struct {
+	options     map[string]PublishFlag
+	properties  map[string]PublishFlag
+	restoreArgs []string
+}
+
+// SplitPublishFlags splits the value of BP_DOTNET_PUBLISH_FLAGS into flags. A
+// value that starts with [ is a JSON array of strings whose elements are used
+// as is. Otherwise the value is split like a shell would, expanding environment
+// variables when expandEnv is set. Shell operators such as ; must be quoted or
+// escaped rather than ending the flags.
+func SplitPublishFlags(raw string, expandEnv bool) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(raw), "[") {
+		return splitJSONFlags(raw)
+	}
+
+	// Environment variables are expanded after the flags are split, as the
+	// expansion of go-shellwords drops escaped and quoted shell operators.
+	parser := shellwords.NewParser()
+
+	flags, err := parser.Parse(raw)
+	if err != nil {
+		index, reason := findShellSyntaxError(raw)
+		if index < 0 {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid flag %q: %s", shellTokenAt(raw, index), reason)
+	}
+
+	if parser.Position >= 0 {
+		return nil, fmt.Errorf("invalid flag %q: %q must be quoted or escaped", shellTokenAt(raw, parser.Position), raw[parser.Position])
+	}
+
+	if expandEnv {
+		for i, flag := range flags {
+			flags[i] = os.Expand(flag, os.Getenv)
+		}
+	}
+
+	return flags, nil
+}
+
+func splitJSONFlags(raw string) ([]string, error) {
+	var elements []json.RawMessage
+	err := json.Unmarshal([]byte(raw), &elements)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset := int(syntaxErr.Offset)
+			return nil, fmt.Errorf("invalid JSON array near %q: %w", raw[max(0, offset-20):offset], err)
+		}
+		return nil, fmt.Errorf("invalid JSON array: %w", err)
+	}
+
+	flags := make([]string, 0, len(elements))
+	for i, element := range elements {
+		var flag string
+		err := json.Unmarshal(element, &flag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON array: element %d (%s) is not a string", i, element)
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, nil
+}
+
+// findShellSyntaxError returns the index of the quote or parenthesis that keeps
+// the given line from being split, and why, or -1 when it finds none.
+func findShellSyntaxError(line string) (int, string) {
+	var (
+		quote      byte
+		quoteStart int
+		escaped    bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote, quoteStart = c, i
+		case c == '(' && i > 0 && line[i-1] == '$':
+			// A command substitution runs until the closing parenthesis.
+			quote, quoteStart = ')', i-1
+		case c == '(' || c == ')':
+			return i, fmt.Sprintf("%q must be quoted or escaped", c)
+		}
+	}
+
+	if quote == ')' {
+		return quoteStart, "unterminated command substitution"
+	}
+
+	if quote != 0 {
+		return quoteStart, fmt.Sprintf("unterminated %c quote", quote)
+	}
+
+	return -1, ""
+}
+
+// shellTokenAt returns the whitespace separated token of the line that
+// contains the given index, leaving quoted whitespace in the token.
+func shellTokenAt(line string, index int) string {
+	var (
+		quote   byte
+		start   int
+		escaped bool
+	)
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == ' ' || c == '\t' || c == '\n':
+			if i > index {
+				return line[start:i]
+			}
+			start = i + 1
+		}
+	}
+
+	return line[start:]
+}
+
+// ParsePublishFlags parses the given flags. It returns an error when an option
+// or property is set more than once, or when flags contradict each other.
+// Options that are not known are passed through to dotnet publish as is.
+func ParsePublishFlags(flags []string) (PublishFlags, error) {
+	parsed := PublishFlags{
+		options:    map[string]PublishFlag{},
+		properties: map[string]PublishFlag{},
+	}
+
+	for i := 0; i < len(flags); i++ {
+		token := flags[i]
+		if !strings.HasPrefix(token, "-") && !strings.HasPrefix(token, "/") {
+			continue
+		}
+
+		start := i
+		name, value, hasValue := splitFlag(token)
+
+		if containsFold(propertyFlagAliases, name) {
+			if !hasValue {
+				if i+1 >= len(flags) {
+					return PublishFlags{}, fmt.Errorf("flag %q requires a value", token)
+				}
+				i++
+				value = flags[i]
+				token = fmt.Sprintf("%s %s", token, value)
+			}
+			parsed.restoreArgs = append(parsed.restoreArgs, flags[start:i+1]...)
+
+			properties, err := splitProperties(value)
+			if err != nil {
+				return PublishFlags{}, fmt.Errorf("invalid flag %q: %w", token, err)
+			}
+
+			for _, property := range properties {
+				err := parsed.addProperty(PublishFlag{Name: property[0], Value: property[1], Token: token})
+				if err != nil {
+					return PublishFlags{}, err
+				}
+			}
+			continue
+		}
+
+		definition, ok := findPublishFlagDefinition(name)
+		if !ok {
+			continue
+		}
+
+		switch definition.value {
+		case flagNoValue:
+			if hasValue {
+				return PublishFlags{}, fmt.Errorf("flag %q does not take a value", token)
+			}
+			value = definition.implied
+
+		case flagRequiredValue:
+			if !hasValue {
+				if i+1 >= len(flags) || isFlag(flags[i+1]) {
+					return PublishFlags{}, fmt.Errorf("flag %q requires a value", token)
+				}
+				i++
+				value = flags[i]
+				token = fmt.Sprintf("%s %s", token, value)
+			}
+
+		case flagOptionalBoolValue:
+			if !hasValue {
+				value = "true"
+				if i+1 < len(flags) && isBool(flags[i+1]) {
+					i++
+					value = flags[i]
+					token = fmt.Sprintf("%s %s", token, value)
+				}
+			}
+
+			if !isBool(value) {
+				return PublishFlags{}, fmt.Errorf("invalid flag %q: value must be true or false", token)
+			}
+			value = strings.ToLower(value)
+
+		case flagOptionalInlineValue:
+			if !hasValue {
+				value = definition.implied
+			}
+		}
+
+		err := parsed.addOption(definition, PublishFlag{Name: definition.name, Value: value, Token: token})
+		if err != nil {
+			return PublishFlags{}, err
+		}
+
+		switch definition.restore {
+		case flagRestoredAsIs:
+			parsed.restoreArgs = append(parsed.restoreArgs, flags[start:i+1]...)
+		case flagRestoredAsProperty:
+			parsed.restoreArgs = append(parsed.restoreArgs, fmt.Sprintf("-p:%s=%s", definition.property, value))
+		}
+	}
+
+	for _, pair := range [][2]string{
+		{"--runtime", "--arch"},
+		{"--runtime", "--os"},
+		{"--runtime", "--use-current-runtime"},
+		{"--arch", "--use-current-runtime"},
+		{"--os", "--use-current-runtime"},
+	} {
+		first, firstOK := parsed.options[pair[0]]
+		second, secondOK := parsed.options[pair[1]]
+		if firstOK && secondOK {
+			return PublishFlags{}, fmt.Errorf("flags %q and %q contradict each other", first.Token, second.Token)
+		}
+	}
+
+	return parsed, nil
+}
+
+// Has reports whether the given option, named by its long form, is set either
+// directly or through its equivalent MSBuild property.
+func (f PublishFlags) Has(name string) bool {
+	_, ok := f.Option(name)
+	return ok
+}
+
+// Option returns the given option, named by its long form. Options that set
+// an MSBuild property are also returned when that property is set with -p.
+func (f PublishFlags) Option(name string) (PublishFlag, bool) {
+	if option, ok := f.options[name]; ok {
+		return option, true
+	}
+
+	definition, ok := findPublishFlagDefinition(name)
+	if ok && definition.property != "" {
+		return f.Property(definition.property)
+	}
+
+	return PublishFlag{}, false
+}
+
+// RestoreArgs returns the flags that also apply to dotnet restore, so that the
+// packages are restored for the same configuration and runtime as they are
+// published for. Options that dotnet restore does not accept are given as the
+// MSBuild properties they set.
+func (f PublishFlags) RestoreArgs() []string {
+	return f.restoreArgs
+}
+
+// Property returns the given MSBuild property. Like in MSBuild, property names
+// are case-insensitive.
+func (f PublishFlags) Property(name string) (PublishFlag, bool) {
+	property, ok := f.properties[strings.ToLower(name)]
+	return property, ok
+}
+
+// GlobalProperties returns the MSBuild global properties that dotnet publish
+// evaluates the project with: the properties set with -p and those set by
+// options such as --configuration.
+func (f PublishFlags) GlobalProperties() map[string]string {
+	properties := map[string]string{}
+	for _, property := range f.properties {
+		properties[property.Name] = property.Value
+	}
+
+	for _, option := range f.options {
+		definition, ok := findPublishFlagDefinition(option.Name)
+		if ok && definition.property != "" {
+			properties[definition.property] = option.Value
+		}
+	}
+
+	return properties
+}
+
+func (f PublishFlags) addOption(definition publishFlagDefinition, option PublishFlag) error {
+	if existing, ok := f.options[option.Name]; ok {
+		return conflictError(existing, option)
+	}
+
+	if definition.property != "" {
+		if existing, ok := f.properties[strings.ToLower(definition.property)]; ok {
+			return conflictError(existing, option)
+		}
+	}
+
+	f.options[option.Name] = option
+	return nil
+}
+
+func (f PublishFlags) addProperty(property PublishFlag) error {
+	key := strings.ToLower(property.Name)
+	if existing, ok := f.properties[key]; ok {
+		return conflictError(existing, property)
+	}
+
+	for _, definition := range publishFlagDefinitions {
+		if strings.EqualFold(definition.property, property.Name) {
+			if existing, ok := f.options[definition.name]; ok {
+				return conflictError(existing, property)
+			}
+		}
+	}
+
+	f.properties[key] = property
+	return nil
+}
+
+func conflictError(existing, flag PublishFlag) error {
+	if strings.EqualFold(existing.Value, flag.Value) {
+		return fmt.Errorf("flag %q is set more than once", flag.Token)
+	}
+
+	return fmt.Errorf("flags %q and %q contradict each other", existing.Token, flag.Token)
+}
+
+func findPublishFlagDefinition(name string) (publishFlagDefinition, bool) {
+	for _, definition := range publishFlagDefinitions {
+		if strings.EqualFold(definition.name, name) || containsFold(definition.aliases, name) {
+			return definition, true
+		}
+	}
+
+	return publishFlagDefinition{}, false
+}
+
+// splitFlag splits a flag such as --output=dir, --output:dir or -p:Name=Value
+// into its name and value.
+func splitFlag(token string) (string, string, bool) {
+	index := strings.IndexAny(token, ":=")
+	if index < 0 {
+		return token, "", false
+	}
+
+	return token[:index], token[index+1:], true
+}
+
+// splitProperties splits the value of a property flag, such as
+// Name=Value;Other=Value, into its properties. Like in MSBuild, a ; within
+// double quotes does not separate properties and the quotes around a value
+// are not part of it. A part that does not assign a property belongs to the
+// value of the previous one, so that values such as DefineConstants=A;B are
+// kept whole.
+func splitProperties(value string) ([][2]string, error) {
+	var properties [][2]string
+	for _, part := range splitUnquoted(value, ';') {
+		name, propertyValue, found := strings.Cut(part, "=")
+		if found && isPropertyName(strings.TrimSpace(name)) {
+			properties = append(properties, [2]string{strings.TrimSpace(name), propertyValue})
+			continue
+		}
+
+		if len(properties) == 0 {
+			return nil, errors.New("property must be of the form Name=Value")
+		}
+
+		properties[len(properties)-1][1] += ";" + part
+	}
+
+	for i, property := range properties {
+		if len(property[1]) >= 2 && strings.HasPrefix(property[1], `"`) && strings.HasSuffix(property[1], `"`) {
+			properties[i][1] = property[1][1 : len(property[1])-1]
+		}
+	}
+
+	return properties, nil
+}
+
+// splitUnquoted splits the value at each separator that is not within double
+// quotes.
+func splitUnquoted(value string, separator rune) []string {
+	var (
+		parts  []string
+		start  int
+		quoted bool
+	)
+
+	for i, c := range value {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case separator:
+			if !quoted {
+				parts = append(parts, value[start:i])
+				start = i + len(string(separator))
+			}
+		}
+	}
+
+	return append(parts, value[start:])
+}
+
+func isPropertyName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if !(c == '_' || c == '-' && i > 0 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isFlag(token string) bool {
+	name, _, _ := splitFlag(token)
+	if containsFold(propertyFlagAliases, name) {
+		return true
+	}
+
+	_, ok := findPublishFlagDefinition(name)
+	return ok
+}
+
+func isBool(value string) bool {
+	return strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+}
+
+func containsFold(values []string, match string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, match) {
+			return true
+		}
+	}
+	return false
+}
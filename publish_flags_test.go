@@ -0,0 +1,238 @@
+package dotnetpublish_test
+
+import (
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPublishFlags(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("SplitPublishFlags", func() {
+		it.Before(func() {
+			t.Setenv("SOME_VERSION", "1.2.3")
+		})
+
+		it("splits the flags like a shell and expands environment variables", func() {
+			flags, err := dotnetpublish.SplitPublishFlags(`--verbosity normal -p:Version=$SOME_VERSION "-p:DefineConstants=A;B"`, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal([]string{"--verbosity", "normal", "-p:Version=1.2.3", "-p:DefineConstants=A;B"}))
+		})
+
+		it("keeps escaped and quoted shell operators when expanding environment variables", func() {
+			flags, err := dotnetpublish.SplitPublishFlags(`-p:A=x\;y -c Release`, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal([]string{"-p:A=x;y", "-c", "Release"}))
+
+			flags, err = dotnetpublish.SplitPublishFlags(`-p:A="x;y",B=$SOME_VERSION`, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal([]string{"-p:A=x;y,B=1.2.3"}))
+
+			flags, err = dotnetpublish.SplitPublishFlags(`-p:A="x y",B=2`, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal([]string{"-p:A=x y,B=2"}))
+		})
+
+		it("does not expand environment variables when expansion is disabled", func() {
+			flags, err := dotnetpublish.SplitPublishFlags(`-p:Version=$SOME_VERSION -p:Pattern=$(Suffix)`, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal([]string{"-p:Version=$SOME_VERSION", "-p:Pattern=$(Suffix)"}))
+		})
+
+		it("uses the elements of a JSON array as is", func() {
+			flags, err := dotnetpublish.SplitPublishFlags(` ["-p:Version=$SOME_VERSION", "-p:DefineConstants=A;B", "--output", "some dir"]`, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags).To(Equal([]string{"-p:Version=$SOME_VERSION", "-p:DefineConstants=A;B", "--output", "some dir"}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error naming the flag with an unterminated quote", func() {
+				_, err := dotnetpublish.SplitPublishFlags(`--verbosity normal '-p:DefineConstants=A;B`, true)
+				Expect(err).To(MatchError(`invalid flag "'-p:DefineConstants=A;B": unterminated ' quote`))
+			})
+
+			it("returns an error naming the flag with an unquoted shell operator", func() {
+				_, err := dotnetpublish.SplitPublishFlags(`-p:DefineConstants=A;B --verbosity normal`, true)
+				Expect(err).To(MatchError(`invalid flag "-p:DefineConstants=A;B": ';' must be quoted or escaped`))
+			})
+
+			it("returns an error naming the flag with an unquoted parenthesis", func() {
+				_, err := dotnetpublish.SplitPublishFlags(`-p:Name=(value)`, true)
+				Expect(err).To(MatchError(`invalid flag "-p:Name=(value)": '(' must be quoted or escaped`))
+			})
+
+			it("returns an error naming the element of a JSON array that is not a string", func() {
+				_, err := dotnetpublish.SplitPublishFlags(`["--verbosity", 1]`, true)
+				Expect(err).To(MatchError("invalid JSON array: element 1 (1) is not a string"))
+			})
+
+			it("returns an error pointing to invalid JSON", func() {
+				_, err := dotnetpublish.SplitPublishFlags(`["--verbosity" "normal"]`, true)
+				Expect(err).To(MatchError(ContainSubstring(`invalid JSON array near "[\"--verbosity\" \""`)))
+			})
+		})
+	})
+
+	context("ParsePublishFlags", func() {
+		it("understands the long, short and MSBuild forms of the options", func() {
+			for _, flags := range [][]string{
+				{"--runtime", "linux-x64"},
+				{"--runtime=linux-x64"},
+				{"--runtime:linux-x64"},
+				{"-r", "linux-x64"},
+				{"-p:RuntimeIdentifier=linux-x64"},
+				{"/p:runtimeidentifier=linux-x64"},
+				{"--property", "RuntimeIdentifier=linux-x64"},
+				{"-property:Configuration=Release;RuntimeIdentifier=linux-x64"},
+			} {
+				publishFlags, err := dotnetpublish.ParsePublishFlags(flags)
+				Expect(err).NotTo(HaveOccurred())
+
+				option, ok := publishFlags.Option("--runtime")
+				Expect(ok).To(BeTrue(), flags[0])
+				Expect(option.Value).To(Equal("linux-x64"))
+			}
+		})
+
+		it("does not mistake other flags for the options", func() {
+			publishFlags, err := dotnetpublish.ParsePublishFlags([]string{"-clp:NoSummary", "-restore", "--output-format", "/some/path", "-bl:out.binlog"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(publishFlags.Has("--configuration")).To(BeFalse())
+			Expect(publishFlags.Has("--runtime")).To(BeFalse())
+			Expect(publishFlags.Has("--output")).To(BeFalse())
+		})
+
+		it("parses the self-contained options", func() {
+			for value, flags := range map[string][]string{
+				"true":  {"--self-contained"},
+				"false": {"--no-self-contained"},
+			} {
+				publishFlags, err := dotnetpublish.ParsePublishFlags(flags)
+				Expect(err).NotTo(HaveOccurred())
+
+				option, ok := publishFlags.Option("--self-contained")
+				Expect(ok).To(BeTrue())
+				Expect(option.Value).To(Equal(value))
+			}
+
+			publishFlags, err := dotnetpublish.ParsePublishFlags([]string{"--sc", "False", "--flag", "value"})
+			Expect(err).NotTo(HaveOccurred())
+
+			option, ok := publishFlags.Option("--self-contained")
+			Expect(ok).To(BeTrue())
+			Expect(option).To(Equal(dotnetpublish.PublishFlag{Name: "--self-contained", Value: "false", Token: "--sc False"}))
+		})
+
+		it("keeps property values that contain semicolons", func() {
+			publishFlags, err := dotnetpublish.ParsePublishFlags([]string{"-p:DefineConstants=FIRST;SECOND;Version=1.2.3"})
+			Expect(err).NotTo(HaveOccurred())
+
+			property, ok := publishFlags.Property("DefineConstants")
+			Expect(ok).To(BeTrue())
+			Expect(property.Value).To(Equal("FIRST;SECOND"))
+
+			property, ok = publishFlags.Property("version")
+			Expect(ok).To(BeTrue())
+			Expect(property.Value).To(Equal("1.2.3"))
+		})
+
+		it("reads quoted property values like MSBuild", func() {
+			publishFlags, err := dotnetpublish.ParsePublishFlags([]string{`-p:DefineConstants="FIRST;Version=2.0";Version=1.2.3`})
+			Expect(err).NotTo(HaveOccurred())
+
+			property, ok := publishFlags.Property("DefineConstants")
+			Expect(ok).To(BeTrue())
+			Expect(property.Value).To(Equal("FIRST;Version=2.0"))
+
+			property, ok = publishFlags.Property("version")
+			Expect(ok).To(BeTrue())
+			Expect(property.Value).To(Equal("1.2.3"))
+		})
+
+		it("returns the flags that apply to dotnet restore", func() {
+			publishFlags, err := dotnetpublish.ParsePublishFlags([]string{
+				"--configuration=Debug",
+				"--sc",
+				"--runtime", "linux-x64",
+				"--property", "Version=1.2.3",
+				"--output", "some-dir",
+				"--framework", "net8.0",
+				"--flag", "value",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(publishFlags.RestoreArgs()).To(Equal([]string{
+				"-p:Configuration=Debug",
+				"-p:SelfContained=true",
+				"--runtime", "linux-x64",
+				"--property", "Version=1.2.3",
+			}))
+		})
+
+		it("returns the global properties that the flags set", func() {
+			publishFlags, err := dotnetpublish.ParsePublishFlags([]string{
+				"-c", "Debug",
+				"--no-self-contained",
+				"-r", "linux-x64",
+				"-p:Version=1.2.3;Flavor=Lite",
+				"--verbosity", "minimal",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(publishFlags.GlobalProperties()).To(Equal(map[string]string{
+				"Configuration":     "Debug",
+				"SelfContained":     "false",
+				"RuntimeIdentifier": "linux-x64",
+				"Version":           "1.2.3",
+				"Flavor":            "Lite",
+			}))
+		})
+
+		context("failure cases", func() {
+			it("reports duplicate flags", func() {
+				_, err := dotnetpublish.ParsePublishFlags([]string{"--configuration", "Release", "-p:Configuration=Release"})
+				Expect(err).To(MatchError(`flag "-p:Configuration=Release" is set more than once`))
+			})
+
+			it("reports binary loggers that are set more than once", func() {
+				_, err := dotnetpublish.ParsePublishFlags([]string{"-bl", "/bl:some.binlog"})
+				Expect(err).To(MatchError(`flags "-bl" and "/bl:some.binlog" contradict each other`))
+			})
+
+			it("reports contradictory flags", func() {
+				_, err := dotnetpublish.ParsePublishFlags([]string{"-c", "Debug", "/p:Configuration=Release"})
+				Expect(err).To(MatchError(`flags "-c Debug" and "/p:Configuration=Release" contradict each other`))
+
+				_, err = dotnetpublish.ParsePublishFlags([]string{"--self-contained", "--no-self-contained"})
+				Expect(err).To(MatchError(`flags "--self-contained" and "--no-self-contained" contradict each other`))
+
+				_, err = dotnetpublish.ParsePublishFlags([]string{"--arch", "arm64", "--runtime", "linux-x64"})
+				Expect(err).To(MatchError(`flags "--runtime linux-x64" and "--arch arm64" contradict each other`))
+			})
+
+			it("reports flags without their value", func() {
+				_, err := dotnetpublish.ParsePublishFlags([]string{"--output"})
+				Expect(err).To(MatchError(`flag "--output" requires a value`))
+
+				_, err = dotnetpublish.ParsePublishFlags([]string{"-o", "--runtime", "linux-x64"})
+				Expect(err).To(MatchError(`flag "-o" requires a value`))
+			})
+
+			it("reports invalid values", func() {
+				_, err := dotnetpublish.ParsePublishFlags([]string{"--self-contained=yes"})
+				Expect(err).To(MatchError(`invalid flag "--self-contained=yes": value must be true or false`))
+
+				_, err = dotnetpublish.ParsePublishFlags([]string{"-p:NotAProperty"})
+				Expect(err).To(MatchError(`invalid flag "-p:NotAProperty": property must be of the form Name=Value`))
+
+				_, err = dotnetpublish.ParsePublishFlags([]string{"--no-restore=true"})
+				Expect(err).To(MatchError(`flag "--no-restore=true" does not take a value`))
+			})
+		})
+	})
+}
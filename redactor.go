@@ -0,0 +1,241 @@
+package dotnetpublish
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactedValue replaces the secrets found in the build log.
+const redactedValue = "***"
+
+// minRedactedLength is the length of the shortest secret that is redacted.
+// Masking shorter values, such as true or 1, would garble the log without
+// protecting anything.
+const minRedactedLength = 4
+
+// sensitivePropertyRe matches the names of MSBuild properties whose values are
+// redacted, such as NuGetPassword or SonarToken.
+var sensitivePropertyRe = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|api_key|credential|connectionstring|privatekey)`)
+
+// defaultRedactionPatterns are always redacted. The subexpression of a pattern
+// is the part of the match that is masked.
+var defaultRedactionPatterns = []*regexp.Regexp{
+	// The password of the credentials of a URL, such as a package source.
+	regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`),
+}
+
+// Redactor masks secrets in the command lines and output of dotnet before they
+// are written to the build log. Secrets are either known values, such as the
+// passwords of a NuGet.Config, or the matches of regular expressions.
+type Redactor struct {
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a redactor for the given regular expression in addition
+// to the default patterns. When the expression has subexpressions, only the
+// parts they match are masked. An empty pattern adds no expression.
+func NewRedactor(pattern string) (Redactor, error) {
+	redactor := Redactor{patterns: defaultRedactionPatterns}
+	if pattern == "" {
+		return redactor, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Redactor{}, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+	}
+
+	redactor.patterns = append(append([]*regexp.Regexp{}, redactor.patterns...), re)
+	return redactor, nil
+}
+
+// WithSecrets returns a redactor that also masks the given values.
+func (r Redactor) WithSecrets(secrets ...string) Redactor {
+	combined := append([]string{}, r.secrets...)
+	for _, secret := range secrets {
+		if len(secret) >= minRedactedLength {
+			combined = append(combined, secret)
+		}
+	}
+
+	// Longer secrets are replaced first so that a secret containing another
+	// one is masked as a whole.
+	sort.SliceStable(combined, func(i, j int) bool {
+		return len(combined[i]) > len(combined[j])
+	})
+
+	r.secrets = combined
+	return r
+}
+
+// Redact returns the given text with its secrets masked.
+func (r Redactor) Redact(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+
+	for _, pattern := range r.patterns {
+		text = redactPattern(text, pattern)
+	}
+
+	return text
+}
+
+// Writer returns a writer that redacts what is written to it line by line
+// before passing it on to w. Close writes the last line when it does not end
+// with a newline.
+func (r Redactor) Writer(w io.Writer) io.WriteCloser {
+	return &redactingWriter{redactor: r, writer: w}
+}
+
+func redactPattern(text string, pattern *regexp.Regexp) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, match := range matches {
+		spans := match[:2]
+		if pattern.NumSubexp() > 0 {
+			spans = match[2:]
+		}
+
+		for i := 0; i < len(spans); i += 2 {
+			// Subexpressions that did not take part in the match are -1,
+			// nested ones may fall within a span that is already masked, and
+			// empty ones have nothing to mask.
+			if spans[i] < last || spans[i] == spans[i+1] {
+				continue
+			}
+			builder.WriteString(text[last:spans[i]])
+			builder.WriteString(redactedValue)
+			last = spans[i+1]
+		}
+	}
+	builder.WriteString(text[last:])
+
+	return builder.String()
+}
+
+type redactingWriter struct {
+	redactor Redactor
+	writer   io.Writer
+	buffer   []byte
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+
+	index := bytes.LastIndexByte(w.buffer, '\n')
+	if index < 0 {
+		return len(p), nil
+	}
+
+	_, err := io.WriteString(w.writer, w.redactor.Redact(string(w.buffer[:index+1])))
+	w.buffer = append(w.buffer[:0], w.buffer[index+1:]...)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *redactingWriter) Close() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	_, err := io.WriteString(w.writer, w.redactor.Redact(string(w.buffer)))
+	w.buffer = nil
+	return err
+}
+
+// sensitivePropertyValues returns the values of the MSBuild properties set by
+// the flags whose names suggest that they are secrets.
+func sensitivePropertyValues(flags PublishFlags) []string {
+	var values []string
+	for _, property := range flags.properties {
+		if sensitivePropertyRe.MatchString(property.Name) {
+			values = append(values, property.Value)
+		}
+	}
+
+	return values
+}
+
+// sensitiveValues returns the values of the properties whose names suggest
+// that they are secrets.
+func (p MSBuildProperties) sensitiveValues() []string {
+	var values []string
+	for name, value := range p {
+		if sensitivePropertyRe.MatchString(name) {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// NuGetConfigSecrets returns the passwords of the package source credentials
+// and the API keys of the NuGet.Config at the given path.
+func NuGetConfigSecrets(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NuGet.Config: %w", err)
+	}
+
+	var (
+		secrets  []string
+		elements []string
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse NuGet.Config: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			elements = append(elements, strings.ToLower(element.Name.Local))
+			if element.Name.Local != "add" || len(elements) < 2 {
+				continue
+			}
+
+			var key, value string
+			for _, attr := range element.Attr {
+				switch attr.Name.Local {
+				case "key":
+					key = attr.Value
+				case "value":
+					value = attr.Value
+				}
+			}
+
+			inCredentials := len(elements) >= 3 && elements[len(elements)-3] == "packagesourcecredentials"
+			inAPIKeys := elements[len(elements)-2] == "apikeys"
+			if inAPIKeys || inCredentials && (strings.EqualFold(key, "Password") || strings.EqualFold(key, "ClearTextPassword")) {
+				secrets = append(secrets, value)
+			}
+
+		case xml.EndElement:
+			elements = elements[:len(elements)-1]
+		}
+	}
+
+	return secrets, nil
+}
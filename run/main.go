@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
+	"time"
 
 	"github.com/Netflix/go-env"
 	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
-	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 	"github.com/paketo-buildpacks/packit/v2/servicebindings"
@@ -36,22 +37,36 @@ func main() {
 		log.Fatal(err)
 	}
 
+	projectParser := dotnetpublish.NewProjectFileParser()
+
 	packit.Run(
 		dotnetpublish.Detect(
 			config,
-			dotnetpublish.NewProjectFileParser(),
+			projectParser,
 		),
 		dotnetpublish.Build(
 			config,
+			projectParser,
 			dotnetpublish.NewDotnetSourceRemover(),
 			bindingResolver,
 			homeDir,
 			symlinker,
 			dotnetpublish.NewDotnetPublishProcess(
-				pexec.NewExecutable("dotnet"),
+				dotnetpublish.NewProcessTreeExecutable("dotnet", 10*time.Second),
+				logger,
+				chronos.DefaultClock,
+			).WithRestoreRetries(config.RestoreAttempts, config.RestoreRetryBackoff).WithTimeout(config.PublishTimeout).WithLogLevel(config.LogLevel),
+			dotnetpublish.NewDotnetTestProcess(
+				dotnetpublish.NewProcessTreeExecutable("dotnet", 10*time.Second),
+				logger,
+				chronos.DefaultClock,
+			).WithLogLevel(config.LogLevel),
+			dotnetpublish.NewShellHookProcess(
+				dotnetpublish.NewProcessTreeExecutable("sh", 10*time.Second),
 				logger,
 				chronos.DefaultClock,
 			),
+			dotnetpublish.NewLinuxRuntimeIdentifierResolver("/", runtime.GOARCH),
 			dotnetpublish.NewOutputSlicer(),
 			chronos.DefaultClock,
 			logger,
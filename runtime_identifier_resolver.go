@@ -0,0 +1,142 @@
+package dotnetpublish
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runtimeIdentifierArchitectures maps Go architectures to the architectures of
+// the .NET runtime identifier graph.
+var runtimeIdentifierArchitectures = map[string]string{
+	"amd64":   "x64",
+	"386":     "x86",
+	"arm64":   "arm64",
+	"arm":     "arm",
+	"s390x":   "s390x",
+	"ppc64le": "ppc64le",
+	"riscv64": "riscv64",
+	"loong64": "loongarch64",
+}
+
+var runtimeIdentifierOperatingSystems = []string{"linux", "linux-musl", "linux-bionic"}
+
+type LinuxRuntimeIdentifierResolver struct {
+	rootDir string
+	arch    string
+}
+
+// NewLinuxRuntimeIdentifierResolver returns a resolver for the build image
+// mounted at rootDir that runs on the given Go architecture.
+func NewLinuxRuntimeIdentifierResolver(rootDir, arch string) LinuxRuntimeIdentifierResolver {
+	return LinuxRuntimeIdentifierResolver{
+		rootDir: rootDir,
+		arch:    arch,
+	}
+}
+
+// Resolve returns the portable Linux runtime identifier of the build image,
+// such as linux-x64 or linux-musl-arm64. When an override is given, it is
+// validated and returned instead.
+func (r LinuxRuntimeIdentifierResolver) Resolve(override string) (string, error) {
+	if override != "" {
+		err := validateRuntimeIdentifier(override)
+		if err != nil {
+			return "", err
+		}
+
+		return override, nil
+	}
+
+	arch, ok := runtimeIdentifierArchitectures[r.arch]
+	if !ok {
+		return "", fmt.Errorf("failed to resolve runtime identifier: unsupported architecture %q", r.arch)
+	}
+
+	musl, err := r.usesMusl()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve runtime identifier: %w", err)
+	}
+
+	if musl {
+		return fmt.Sprintf("linux-musl-%s", arch), nil
+	}
+
+	return fmt.Sprintf("linux-%s", arch), nil
+}
+
+// usesMusl reports whether the C library of the image is musl, either because
+// os-release identifies an Alpine based distribution or because the musl
+// dynamic loader is installed.
+func (r LinuxRuntimeIdentifierResolver) usesMusl() (bool, error) {
+	for _, path := range []string{"etc/os-release", "usr/lib/os-release"} {
+		release, err := parseOSRelease(filepath.Join(r.rootDir, path))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return false, err
+		}
+
+		for _, id := range append([]string{release["ID"]}, strings.Fields(release["ID_LIKE"])...) {
+			if id == "alpine" {
+				return true, nil
+			}
+		}
+		break
+	}
+
+	for _, dir := range []string{"lib", "usr/lib"} {
+		matches, err := filepath.Glob(filepath.Join(r.rootDir, dir, "ld-musl-*.so.1"))
+		if err != nil {
+			return false, err
+		}
+
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func parseOSRelease(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	release := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found || strings.HasPrefix(key, "#") {
+			continue
+		}
+
+		release[key] = strings.Trim(value, `"'`)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return release, nil
+}
+
+func validateRuntimeIdentifier(runtimeIdentifier string) error {
+	for _, system := range runtimeIdentifierOperatingSystems {
+		arch := strings.TrimPrefix(runtimeIdentifier, system+"-")
+		for _, supported := range runtimeIdentifierArchitectures {
+			if arch == supported && arch != runtimeIdentifier {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("invalid runtime identifier %q: must be a portable Linux runtime identifier such as linux-x64 or linux-musl-arm64", runtimeIdentifier)
+}
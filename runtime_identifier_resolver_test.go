@@ -0,0 +1,105 @@
+package dotnetpublish_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLinuxRuntimeIdentifierResolver(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		rootDir string
+	)
+
+	it.Before(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "root-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(rootDir, "etc"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rootDir, "etc", "os-release"), []byte(`NAME="Ubuntu"
+ID=ubuntu
+ID_LIKE=debian
+`), 0600)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	it("maps the architecture to the runtime identifier graph", func() {
+		for arch, expected := range map[string]string{
+			"amd64":   "linux-x64",
+			"arm64":   "linux-arm64",
+			"arm":     "linux-arm",
+			"s390x":   "linux-s390x",
+			"ppc64le": "linux-ppc64le",
+			"riscv64": "linux-riscv64",
+		} {
+			runtimeIdentifier, err := dotnetpublish.NewLinuxRuntimeIdentifierResolver(rootDir, arch).Resolve("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeIdentifier).To(Equal(expected))
+		}
+	})
+
+	context("when the image is Alpine based", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(rootDir, "etc", "os-release"), []byte(`NAME="Alpine Linux"
+ID=alpine
+`), 0600)).To(Succeed())
+		})
+
+		it("returns a musl runtime identifier", func() {
+			runtimeIdentifier, err := dotnetpublish.NewLinuxRuntimeIdentifierResolver(rootDir, "amd64").Resolve("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeIdentifier).To(Equal("linux-musl-x64"))
+		})
+	})
+
+	context("when the musl dynamic loader is installed", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(rootDir, "etc", "os-release"))).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(rootDir, "lib"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(rootDir, "lib", "ld-musl-aarch64.so.1"), nil, 0600)).To(Succeed())
+		})
+
+		it("returns a musl runtime identifier", func() {
+			runtimeIdentifier, err := dotnetpublish.NewLinuxRuntimeIdentifierResolver(rootDir, "arm64").Resolve("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeIdentifier).To(Equal("linux-musl-arm64"))
+		})
+	})
+
+	context("when an override is given", func() {
+		it("returns it", func() {
+			runtimeIdentifier, err := dotnetpublish.NewLinuxRuntimeIdentifierResolver(rootDir, "amd64").Resolve("linux-musl-arm64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeIdentifier).To(Equal("linux-musl-arm64"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the architecture is not supported", func() {
+			it("returns an error", func() {
+				_, err := dotnetpublish.NewLinuxRuntimeIdentifierResolver(rootDir, "mips").Resolve("")
+				Expect(err).To(MatchError(`failed to resolve runtime identifier: unsupported architecture "mips"`))
+			})
+		})
+
+		context("when the override is not a valid runtime identifier", func() {
+			it("returns an error", func() {
+				for _, override := range []string{"win-x64", "linux-amd64", "linux", "ubuntu.22.04-x64", "Linux-X64"} {
+					_, err := dotnetpublish.NewLinuxRuntimeIdentifierResolver(rootDir, "amd64").Resolve(override)
+					Expect(err).To(MatchError(ContainSubstring("invalid runtime identifier %q", override)))
+				}
+			})
+		})
+	})
+}
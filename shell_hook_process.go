@@ -0,0 +1,66 @@
+package dotnetpublish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// ShellHookProcess runs the commands that users hook into the build before
+// and after the app is published.
+type ShellHookProcess struct {
+	executable Executable
+	logger     scribe.Emitter
+	clock      chronos.Clock
+}
+
+// NewShellHookProcess returns a process that runs hook commands with the given
+// shell executable, which is invoked as <shell> -c <command>.
+func NewShellHookProcess(executable Executable, logger scribe.Emitter, clock chronos.Clock) ShellHookProcess {
+	return ShellHookProcess{
+		executable: executable,
+		logger:     logger,
+		clock:      clock,
+	}
+}
+
+// Execute runs the command of the named hook in the working directory, with
+// the environment that dotnet runs with. The publish output directory is
+// given to the command as $PUBLISH_OUTPUT. Like the output of dotnet, the
+// command and its output are logged with the secrets of the redactor masked.
+func (p ShellHookProcess) Execute(name, command, workingDir, nugetCachePath, outputPath string, redactor Redactor) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	p.logger.Subprocess("Running '%s'", redactor.Redact(command))
+
+	writer := redactor.Writer(p.logger.ActionWriter)
+	duration, err := p.clock.Measure(func() error {
+		err := p.executable.Execute(ctx, pexec.Execution{
+			Args:   []string{"-c", command},
+			Dir:    workingDir,
+			Env:    append(environment(nugetCachePath), fmt.Sprintf("PUBLISH_OUTPUT=%s", outputPath)),
+			Stdout: writer,
+			Stderr: writer,
+		})
+		return errors.Join(err, writer.Close())
+	})
+	if err != nil {
+		p.logger.Action("Failed after %s", duration)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("failed to run %s command: %w", name, err)
+	}
+
+	p.logger.Action("Completed in %s", duration)
+	p.logger.Break()
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package dotnetpublish_test
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	dotnetpublish "github.com/paketo-buildpacks/dotnet-publish"
+	"github.com/paketo-buildpacks/dotnet-publish/fakes"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	. "github.com/paketo-buildpacks/occam/matchers"
+)
+
+func testShellHookProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+		execution  pexec.Execution
+		process    dotnetpublish.ShellHookProcess
+
+		buffer *bytes.Buffer
+	)
+
+	it.Before(func() {
+		executable = &fakes.Executable{}
+
+		buffer = bytes.NewBuffer(nil)
+		logger := scribe.NewEmitter(buffer)
+
+		now := time.Now()
+		times := []time.Time{now, now.Add(1 * time.Second)}
+
+		clock := chronos.NewClock(func() time.Time {
+			if len(times) == 0 {
+				return time.Now()
+			}
+
+			t := times[0]
+			times = times[1:]
+			return t
+		})
+
+		executable.ExecuteCall.Stub = func(ctx gocontext.Context, e pexec.Execution) error {
+			execution = e
+
+			_, err := fmt.Fprintln(execution.Stdout, "Bundling migrations with token some-token")
+			Expect(err).ToNot(HaveOccurred())
+
+			return nil
+		}
+
+		process = dotnetpublish.NewShellHookProcess(executable, logger, clock)
+	})
+
+	it("runs the command with the environment of dotnet and the publish output", func() {
+		redactor := dotnetpublish.Redactor{}.WithSecrets("some-token")
+
+		err := process.Execute("pre-publish", "dotnet ef migrations bundle --token some-token", "some-working-dir", "some/nuget/cache/path", "some-publish-output-dir", redactor)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(execution.Args).To(Equal([]string{"-c", "dotnet ef migrations bundle --token some-token"}))
+		Expect(execution.Dir).To(Equal("some-working-dir"))
+		Expect(execution.Env).To(ContainElements(
+			"NUGET_PACKAGES=some/nuget/cache/path",
+			"DOTNET_NOLOGO=true",
+			"PUBLISH_OUTPUT=some-publish-output-dir",
+		))
+
+		Expect(buffer.String()).To(ContainLines(
+			"    Running 'dotnet ef migrations bundle --token ***'",
+			"      Bundling migrations with token ***",
+			"      Completed in 1s",
+		))
+		Expect(buffer.String()).NotTo(ContainSubstring("some-token"))
+	})
+
+	context("failure cases", func() {
+		context("when the command fails", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(gocontext.Context, pexec.Execution) error {
+					return errors.New("exit status 2")
+				}
+			})
+
+			it("returns an error naming the hook", func() {
+				err := process.Execute("post-publish", "./stamp.sh", "some-working-dir", "some/nuget/cache/path", "some-publish-output-dir", dotnetpublish.Redactor{})
+				Expect(err).To(MatchError("failed to run post-publish command: exit status 2"))
+
+				Expect(buffer.String()).To(ContainLines(
+					"    Running './stamp.sh'",
+					"      Failed after 1s",
+				))
+			})
+		})
+	})
+}